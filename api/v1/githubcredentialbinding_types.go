@@ -0,0 +1,59 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NamespacedSecretKeyRef points at a key of a Secret in any namespace.
+type NamespacedSecretKeyRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Key holding the access token, defaults to "token". Secrets holding github App credentials ignore it.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+type GithubCredentialBindingSpec struct {
+	// Host is the configured host (github.com, a GitHub Enterprise Server, gitlab, gitea or jira host) of the repositories, defaults to github.com.
+	// +kubebuilder:default=github.com
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Repositories are owner/name glob patterns (e.g. "my-org/*" or "my-org/api-*") the binding applies to, gitlab owners include their subgroups.
+	// When several bindings match a repo the one with the most specific pattern wins, an exact owner/name beats any glob.
+	// +kubebuilder:validation:MinItems=1
+	Repositories []string `json:"repositories"`
+	// NamespaceSelector limits the binding to GithubIssues in matching namespaces, when empty every namespace is selected.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// SecretRef is the secret holding a personal access token or github App credentials.
+	SecretRef NamespacedSecretKeyRef `json:"secretRef"`
+}
+
+type GithubCredentialBindingStatus struct {
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+
+// GithubCredentialBinding routes the GithubIssues of matching repositories without a credentialsRef to a shared secret.
+type GithubCredentialBinding struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   GithubCredentialBindingSpec   `json:"spec,omitempty"`
+	Status GithubCredentialBindingStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// GithubCredentialBindingList contains a list of GithubCredentialBinding
+type GithubCredentialBindingList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GithubCredentialBinding `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&GithubCredentialBinding{}, &GithubCredentialBindingList{})
+}
@@ -4,18 +4,197 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// DefaultGithubHost is the host of repos and credential bindings that do not name a GitHub Enterprise Server host.
+const DefaultGithubHost = "github.com"
+
+// DeletionPolicy describes what happens to the remote issue when the GithubIssue is deleted.
+// +kubebuilder:validation:Enum=Close;Lock;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyClose closes the remote issue.
+	DeletionPolicyClose DeletionPolicy = "Close"
+	// DeletionPolicyLock closes the remote issue and locks its conversation.
+	DeletionPolicyLock DeletionPolicy = "Lock"
+	// DeletionPolicyOrphan leaves the remote issue untouched.
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
+// IssueState is the wanted state of the remote issue.
+// +kubebuilder:validation:Enum=open;closed
+type IssueState string
+
+const (
+	IssueStateOpen   IssueState = "open"
+	IssueStateClosed IssueState = "closed"
+)
+
+// IssueStateReason is the reason github records when an issue is closed.
+// +kubebuilder:validation:Enum=completed;not_planned
+type IssueStateReason string
+
+const (
+	IssueStateReasonCompleted  IssueStateReason = "completed"
+	IssueStateReasonNotPlanned IssueStateReason = "not_planned"
+)
+
+// SyncPolicy describes who owns the content of the remote issue once it exists.
+// +kubebuilder:validation:Enum=Enforce;Adopt;Observe
+type SyncPolicy string
+
+const (
+	// SyncPolicyEnforce overwrites every remote change with the spec.
+	SyncPolicyEnforce SyncPolicy = "Enforce"
+	// SyncPolicyAdopt opens the issue from the spec and then records remote edits in the status without overwriting them.
+	SyncPolicyAdopt SyncPolicy = "Adopt"
+	// SyncPolicyObserve never writes to the remote, not even to open or close the issue.
+	SyncPolicyObserve SyncPolicy = "Observe"
+)
+
+// IssuePhase summarizes the conditions of a GithubIssue.
+// +kubebuilder:validation:Enum=Pending;Open;Closed;Failed;Terminating
+type IssuePhase string
+
+const (
+	// IssuePhasePending is reported until the remote issue exists.
+	IssuePhasePending IssuePhase = "Pending"
+	// IssuePhaseOpen and IssuePhaseClosed report the state of a remote issue that is in sync.
+	IssuePhaseOpen   IssuePhase = "Open"
+	IssuePhaseClosed IssuePhase = "Closed"
+	// IssuePhaseFailed is reported when the last reconcile failed.
+	IssuePhaseFailed IssuePhase = "Failed"
+	// IssuePhaseTerminating is reported while the deletion policy is retried.
+	IssuePhaseTerminating IssuePhase = "Terminating"
+)
+
+// SecretKeyRef points at a key of a Secret in the namespace of the GithubIssue.
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	// Key holding the access token, defaults to "token". Secrets holding github App credentials ignore it.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
 type GithubIssueSpec struct {
 	Repo        string `json:"repo"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
+
+	// Labels to keep on the remote issue, labels missing here are removed from it.
+	// When empty the remote labels are left untouched.
+	// +optional
+	Labels []string `json:"labels,omitempty"`
+	// Assignees (github logins) to keep on the remote issue, when empty the remote assignees are left untouched.
+	// +optional
+	Assignees []string `json:"assignees,omitempty"`
+	// Milestone is the title of an existing milestone of the repo, when empty the remote milestone is left untouched.
+	// +optional
+	Milestone string `json:"milestone,omitempty"`
+
+	// IssueType is the jira issue type (e.g. Story or Bug) of the work item, defaults to the type configured for the jira host.
+	// Only jira repos accept it.
+	// +optional
+	IssueType string `json:"issueType,omitempty"`
+
+	// State of the remote issue, flipping it back to open reopens a closed issue.
+	// +kubebuilder:default=open
+	// +optional
+	State IssueState `json:"state,omitempty"`
+	// StateReason is sent as the state_reason while State is closed.
+	// +optional
+	StateReason IssueStateReason `json:"stateReason,omitempty"`
+
+	// CredentialsRef is the secret holding the credentials used for the remote, it can be shared by many issues.
+	// +optional
+	CredentialsRef *SecretKeyRef `json:"credentialsRef,omitempty"`
+	// CreateCredentialsSecret opts in to a placeholder secret named <name>-github-token-secret being created
+	// when no CredentialsRef is set, the access token then has to be filled in by hand.
+	// +optional
+	CreateCredentialsSecret bool `json:"createCredentialsSecret,omitempty"`
+
+	// SyncPolicy decides whether remote edits are overwritten (Enforce), adopted (Adopt) or only reported (Observe), defaults to Enforce.
+	// +kubebuilder:default=Enforce
+	// +optional
+	SyncPolicy SyncPolicy `json:"syncPolicy,omitempty"`
+
+	// DeletionPolicy is applied to the remote issue when this object is deleted, defaults to Close.
+	// +kubebuilder:default=Close
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+	// DeletionStateReason is sent as the state_reason when the remote issue is closed on deletion.
+	// +optional
+	DeletionStateReason IssueStateReason `json:"deletionStateReason,omitempty"`
+	// DeletionComment is posted on the remote issue before it is closed on deletion.
+	// +optional
+	DeletionComment string `json:"deletionComment,omitempty"`
+}
+
+// LinkedPullRequest is a pull request referencing the remote issue.
+type LinkedPullRequest struct {
+	// Repo is the owner/name of the repository of the pull request.
+	Repo   string `json:"repo"`
+	Number int    `json:"number"`
+	URL    string `json:"url,omitempty"`
+	// State is open or closed, a merged pull request is closed.
+	State  string `json:"state"`
+	Merged bool   `json:"merged"`
 }
 
 type GithubIssueStatus struct {
+	// Conditons hold a single condition per type, Ready summarizes the others.
 	Conditons []metav1.Condition `json:"conditions"`
+	// ObservedGeneration is the generation of the spec the status was last reconciled against.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Phase is a summary of the Ready condition and the remote state.
+	// +optional
+	Phase IssuePhase `json:"phase,omitempty"`
+
+	// IssueNumber is the number of the remote issue managed by this object, 0 until the issue is opened.
+	// +optional
+	IssueNumber int `json:"issueNumber,omitempty"`
+	// IssueKey is the key of the remote issue on trackers identifying issues by key (e.g. PROJ-12 on jira).
+	// +optional
+	IssueKey string `json:"issueKey,omitempty"`
+	// +optional
+	HTMLURL string `json:"htmlURL,omitempty"`
+	// State is the remote state of the issue (open or closed).
+	// +optional
+	State string `json:"state,omitempty"`
+	// RemoteTitle is the title of the remote issue as of the last sync.
+	// +optional
+	RemoteTitle string `json:"remoteTitle,omitempty"`
+	// RemoteBody is the body of the remote issue as of the last sync.
+	// +optional
+	RemoteBody string `json:"remoteBody,omitempty"`
+	// RemoteUpdatedAt is the last time the issue was updated on the remote.
+	// +optional
+	RemoteUpdatedAt *metav1.Time `json:"remoteUpdatedAt,omitempty"`
+	// LastSyncedTime is the last time the remote issue was successfully reconciled.
+	// +optional
+	LastSyncedTime *metav1.Time `json:"lastSyncedTime,omitempty"`
+	// PullRequests referencing the remote issue or closing it, development sidebar links included.
+	// +optional
+	PullRequests []LinkedPullRequest `json:"pullRequests,omitempty"`
+	// OpenedAt, FirstPullRequestLinkedAt and ClosedAt are the remote timestamps of the issue lifecycle,
+	// the lifecycle metrics are recorded once when each of them is first set.
+	// +optional
+	OpenedAt *metav1.Time `json:"openedAt,omitempty"`
+	// +optional
+	FirstPullRequestLinkedAt *metav1.Time `json:"firstPullRequestLinkedAt,omitempty"`
+	// ClosedAt is cleared when the remote issue is reopened.
+	// +optional
+	ClosedAt *metav1.Time `json:"closedAt,omitempty"`
+	// DeletionAttempts counts the failed attempts to apply the deletion policy.
+	// +optional
+	DeletionAttempts int `json:"deletionAttempts,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // GithubIssue is the Schema for the githubissues API
 type GithubIssue struct {
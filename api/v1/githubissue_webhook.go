@@ -17,19 +17,29 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"net/url"
 	"regexp"
+	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
 )
 
 // log is for logging in this package.
 var githubissuelog = logf.Log.WithName("githubissue-resource")
 
+// loadedConfig holds the github hosts repo urls are validated against.
+var loadedConfig, _ = config.LoadConfig()
+
 // SetupWebhookWithManager will setup the manager to manage the webhooks
 func (r *GithubIssue) SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr).
@@ -50,14 +60,14 @@ var _ webhook.Validator = &GithubIssue{}
 func (r *GithubIssue) ValidateCreate() (admission.Warnings, error) {
 	githubissuelog.Info("validate create", "name", r.Name)
 
-	return nil, r.validateRepoInputIsOk(r.Spec.Repo, field.NewPath("spec").Child("repo"))
+	return r.validateGithubIssue()
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *GithubIssue) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
 	githubissuelog.Info("validate update", "name", r.Name)
 
-	return nil, r.validateRepoInputIsOk(r.Spec.Repo, field.NewPath("spec").Child("repo"))
+	return r.validateGithubIssue()
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -67,14 +77,120 @@ func (r *GithubIssue) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
 
+func (r *GithubIssue) validateGithubIssue() (admission.Warnings, error) {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if err := r.validateRepoInputIsOk(r.Spec.Repo, specPath.Child("repo")); err != nil {
+		allErrs = append(allErrs, err)
+	} else {
+		allErrs = append(allErrs, r.validateProviderFields(specPath)...)
+	}
+
+	warnings, errs := r.validateCredentials(specPath)
+	allErrs = append(allErrs, errs...)
+
+	if len(allErrs) == 0 {
+		return warnings, nil
+	}
+
+	return warnings, apierrors.NewInvalid(GroupVersion.WithKind("GithubIssue").GroupKind(), r.Name, allErrs)
+}
+
+// validateRepoInputIsOk accepts https://<host>/<owner>/<repo> urls on one of the configured hosts,
+// on gitlab hosts the project may sit in nested groups and on jira hosts the url is https://<host>/browse/<project key>.
 func (r *GithubIssue) validateRepoInputIsOk(providedRepo string, fieldPath *field.Path) *field.Error {
-	patternRegex := `^https:\/\/github\.com\/[\w-]+\/[\w-]+$`
+	for _, host := range loadedConfig.Hosts {
+		pathRegex := `\/[\w-]+\/[\w-]+$`
+		switch host.Provider {
+		case config.ProviderGitlab:
+			pathRegex = `(\/[\w.-]+){2,}$`
+		case config.ProviderJira:
+			pathRegex = `\/browse\/[A-Z][A-Z0-9_]+$`
+		}
+
+		regex := regexp.MustCompile(`^https:\/\/(?i:` + regexp.QuoteMeta(host.Host) + `)` + pathRegex)
+
+		if regex.MatchString(providedRepo) {
+			return nil
+		}
+	}
+
+	return field.Invalid(fieldPath, providedRepo, fmt.Sprintf("The provided repo is not a repo url on one of the configured hosts (%s)", strings.Join(loadedConfig.HostNames(), ", ")))
+}
+
+// validateProviderFields rejects the fields the provider of the repo's host cannot honour.
+func (r *GithubIssue) validateProviderFields(specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	repoUrl, err := url.Parse(r.Spec.Repo)
+	if err != nil {
+		return nil
+	}
+
+	host, ok := loadedConfig.FindHost(repoUrl.Host)
+	if !ok {
+		return nil
+	}
+
+	if host.Provider != config.ProviderJira && r.Spec.IssueType != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("issueType"), "only jira issues have an issue type"))
+	}
 
-	regex := regexp.MustCompile(patternRegex)
+	if host.Provider == config.ProviderGithub {
+		return allErrs
+	}
+
+	if r.Spec.StateReason != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("stateReason"), fmt.Sprintf("%s issues have no state reason", host.Provider)))
+	}
+
+	if r.Spec.DeletionStateReason != "" {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("deletionStateReason"), fmt.Sprintf("%s issues have no state reason", host.Provider)))
+	}
+
+	if host.Provider == config.ProviderJira && len(r.Spec.Assignees) != 0 {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("assignees"), "jira issues cannot be assigned by login"))
+	}
+
+	if (host.Provider == config.ProviderGitea || host.Provider == config.ProviderJira) && r.Spec.DeletionPolicy == DeletionPolicyLock {
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("deletionPolicy"), r.Spec.DeletionPolicy,
+			[]string{string(DeletionPolicyClose), string(DeletionPolicyOrphan)}))
+	}
+
+	return allErrs
+}
+
+// validateCredentials checks the credentials reference is a valid secret name and key,
+// the secret itself may be created after the issue so its existence is left to the reconciler.
+func (r *GithubIssue) validateCredentials(specPath *field.Path) (admission.Warnings, field.ErrorList) {
+	var allErrs field.ErrorList
+	credentialsRef := r.Spec.CredentialsRef
+
+	if credentialsRef == nil {
+		if !r.Spec.CreateCredentialsSecret {
+			return admission.Warnings{"spec.credentialsRef is not set, the credentials are read from a matching GithubCredentialBinding " +
+				"or else from the secret " + r.Name + "-github-token-secret which has to be created by hand"}, nil
+		}
+
+		return nil, nil
+	}
+
+	refPath := specPath.Child("credentialsRef")
+
+	if r.Spec.CreateCredentialsSecret {
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("createCredentialsSecret"), "cannot be set together with credentialsRef"))
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(credentialsRef.Name) {
+		allErrs = append(allErrs, field.Invalid(refPath.Child("name"), credentialsRef.Name, msg))
+	}
 
-	if !regex.MatchString(providedRepo) {
-		return field.Invalid(fieldPath, providedRepo, "The provided repo doesnt meet the github.com repo url requirments")
+	if credentialsRef.Key != "" {
+		for _, msg := range validation.IsConfigMapKey(credentialsRef.Key) {
+			allErrs = append(allErrs, field.Invalid(refPath.Child("key"), credentialsRef.Key, msg))
+		}
 	}
 
-	return nil
+	return nil, allErrs
 }
@@ -18,6 +18,10 @@ package v1
 
 import (
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
 )
 
 var _ = Describe("GithubIssue Webhook", func() {
@@ -36,4 +40,142 @@ var _ = Describe("GithubIssue Webhook", func() {
 		})
 	})
 
+	Context("When validating the credentials reference", func() {
+		newGithubIssue := func(credentialsRef *SecretKeyRef) *GithubIssue {
+			return &GithubIssue{
+				ObjectMeta: metav1.ObjectMeta{Name: "issue", Namespace: "default"},
+				Spec: GithubIssueSpec{
+					Repo:           "https://github.com/owner/repo",
+					Title:          "title",
+					CredentialsRef: credentialsRef,
+				},
+			}
+		}
+
+		It("Should admit a valid reference", func() {
+			warnings, err := newGithubIssue(&SecretKeyRef{Name: "shared-github-token", Key: "token"}).ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("Should deny an invalid secret name or key", func() {
+			_, err := newGithubIssue(&SecretKeyRef{Name: "Not_A_Name"}).ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			_, err = newGithubIssue(&SecretKeyRef{Name: "shared", Key: "bad/key"}).ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should deny a reference together with the auto created secret", func() {
+			githubIssue := newGithubIssue(&SecretKeyRef{Name: "shared"})
+			githubIssue.Spec.CreateCredentialsSecret = true
+
+			_, err := githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should warn when no credentials are configured", func() {
+			warnings, err := newGithubIssue(nil).ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+	})
+
+	Context("When validating the repo url", func() {
+		var configuredHosts []config.Host
+
+		BeforeEach(func() {
+			configuredHosts = loadedConfig.Hosts
+			loadedConfig.Hosts = append([]config.Host{}, configuredHosts...)
+			loadedConfig.Hosts = append(loadedConfig.Hosts,
+				config.Host{Host: "github.example.com", Provider: config.ProviderGithub},
+				config.Host{Host: "gitea.example.com", Provider: config.ProviderGitea},
+				config.Host{Host: "jira.example.com", Provider: config.ProviderJira})
+		})
+
+		AfterEach(func() {
+			loadedConfig.Hosts = configuredHosts
+		})
+
+		newGithubIssue := func(repo string) *GithubIssue {
+			return &GithubIssue{
+				ObjectMeta: metav1.ObjectMeta{Name: "issue", Namespace: "default"},
+				Spec: GithubIssueSpec{
+					Repo:           repo,
+					Title:          "title",
+					CredentialsRef: &SecretKeyRef{Name: "shared-github-token"},
+				},
+			}
+		}
+
+		It("Should admit repos on every configured host", func() {
+			_, err := newGithubIssue("https://github.com/owner/repo").ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newGithubIssue("https://github.example.com/owner/repo").ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should admit gitlab projects in nested groups", func() {
+			_, err := newGithubIssue("https://gitlab.com/group/subgroup/project.name").ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newGithubIssue("https://github.com/group/subgroup/project").ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should deny state reasons on gitlab projects", func() {
+			githubIssue := newGithubIssue("https://gitlab.com/group/project")
+			githubIssue.Spec.StateReason = IssueStateReasonNotPlanned
+
+			_, err := githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should deny the lock deletion policy on gitea repos", func() {
+			githubIssue := newGithubIssue("https://gitea.example.com/owner/repo")
+			_, err := githubIssue.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			githubIssue.Spec.DeletionPolicy = DeletionPolicyLock
+			_, err = githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should admit jira projects and only let them set an issue type", func() {
+			githubIssue := newGithubIssue("https://jira.example.com/browse/PROJ")
+			githubIssue.Spec.IssueType = "Story"
+			_, err := githubIssue.ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = newGithubIssue("https://jira.example.com/owner/repo").ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			githubIssue = newGithubIssue("https://github.com/owner/repo")
+			githubIssue.Spec.IssueType = "Story"
+			_, err = githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should deny assignees and the lock deletion policy on jira projects", func() {
+			githubIssue := newGithubIssue("https://jira.example.com/browse/PROJ")
+			githubIssue.Spec.Assignees = []string{"someone"}
+			_, err := githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			githubIssue.Spec.Assignees = nil
+			githubIssue.Spec.DeletionPolicy = DeletionPolicyLock
+			_, err = githubIssue.ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should deny repos on other hosts", func() {
+			_, err := newGithubIssue("https://bitbucket.org/owner/repo").ValidateCreate()
+			Expect(err).To(HaveOccurred())
+
+			_, err = newGithubIssue("https://github.example.com.evil.io/owner/repo").ValidateCreate()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 })
@@ -25,12 +25,112 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubCredentialBinding) DeepCopyInto(out *GithubCredentialBinding) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubCredentialBinding.
+func (in *GithubCredentialBinding) DeepCopy() *GithubCredentialBinding {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubCredentialBinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubCredentialBinding) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubCredentialBindingList) DeepCopyInto(out *GithubCredentialBindingList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GithubCredentialBinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubCredentialBindingList.
+func (in *GithubCredentialBindingList) DeepCopy() *GithubCredentialBindingList {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubCredentialBindingList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GithubCredentialBindingList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubCredentialBindingSpec) DeepCopyInto(out *GithubCredentialBindingSpec) {
+	*out = *in
+	if in.Repositories != nil {
+		in, out := &in.Repositories, &out.Repositories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubCredentialBindingSpec.
+func (in *GithubCredentialBindingSpec) DeepCopy() *GithubCredentialBindingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubCredentialBindingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GithubCredentialBindingStatus) DeepCopyInto(out *GithubCredentialBindingStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubCredentialBindingStatus.
+func (in *GithubCredentialBindingStatus) DeepCopy() *GithubCredentialBindingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(GithubCredentialBindingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubIssue) DeepCopyInto(out *GithubIssue) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -87,6 +187,21 @@ func (in *GithubIssueList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubIssueSpec) DeepCopyInto(out *GithubIssueSpec) {
 	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Assignees != nil {
+		in, out := &in.Assignees, &out.Assignees
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CredentialsRef != nil {
+		in, out := &in.CredentialsRef, &out.CredentialsRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubIssueSpec.
@@ -109,6 +224,31 @@ func (in *GithubIssueStatus) DeepCopyInto(out *GithubIssueStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RemoteUpdatedAt != nil {
+		in, out := &in.RemoteUpdatedAt, &out.RemoteUpdatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSyncedTime != nil {
+		in, out := &in.LastSyncedTime, &out.LastSyncedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PullRequests != nil {
+		in, out := &in.PullRequests, &out.PullRequests
+		*out = make([]LinkedPullRequest, len(*in))
+		copy(*out, *in)
+	}
+	if in.OpenedAt != nil {
+		in, out := &in.OpenedAt, &out.OpenedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.FirstPullRequestLinkedAt != nil {
+		in, out := &in.FirstPullRequestLinkedAt, &out.FirstPullRequestLinkedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ClosedAt != nil {
+		in, out := &in.ClosedAt, &out.ClosedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GithubIssueStatus.
@@ -120,3 +260,48 @@ func (in *GithubIssueStatus) DeepCopy() *GithubIssueStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinkedPullRequest) DeepCopyInto(out *LinkedPullRequest) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinkedPullRequest.
+func (in *LinkedPullRequest) DeepCopy() *LinkedPullRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(LinkedPullRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedSecretKeyRef) DeepCopyInto(out *NamespacedSecretKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedSecretKeyRef.
+func (in *NamespacedSecretKeyRef) DeepCopy() *NamespacedSecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedSecretKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyRef) DeepCopyInto(out *SecretKeyRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyRef.
+func (in *SecretKeyRef) DeepCopy() *SecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
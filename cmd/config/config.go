@@ -4,68 +4,128 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-
-	"github.com/joho/godotenv"
+	"strings"
 )
 
 type Config struct {
 	AuthSecret struct {
 		GithubSecretName    string `json:"githubSecretName"`
 		GithubSecretKeyName string `json:"githubSecretKeyName"`
+		// AuthTypeKeyName selects the auth mode of a secret, "token" (the default) or "app".
+		AuthTypeKeyName string `json:"authTypeKeyName"`
+		// The keys holding the github App credentials when the auth type is "app".
+		AppIDKeyName          string `json:"appIDKeyName"`
+		InstallationIDKeyName string `json:"installationIDKeyName"`
+		PrivateKeyKeyName     string `json:"privateKeyKeyName"`
+	}
+	FinalizerKey  string `json:"finalizerKey"`
+	RepoLabelKey  string `json:"repoLabelKey"`
+	TitleLabelKey string `json:"titleLabelKey"`
+	// DeletionRetryLimit is how many times the deletion policy is retried before the finalizer is removed anyway.
+	DeletionRetryLimit int `json:"deletionRetryLimit"`
+	// Hosts are the forges repos may live on, github.com, GitHub Enterprise Server, gitlab and gitea instances,
+	// and the jira sites whose projects stand in for repos.
+	Hosts     []Host `json:"hosts"`
+	GithubApi struct {
+		PerPage int `json:"perPage"`
+		// CacheTTLSeconds is how long the shared issue index of a repo is served before it is listed again,
+		// and how long the github client replays a stored response on 304 Not Modified.
+		CacheTTLSeconds int `json:"cacheTTLSeconds"`
 	}
-	UserGithubToken string `json:"userGithubToken"`
-	FinalizerKey    string `json:"finalizerKey"`
-	EnvName         string `json:"envName"`
-	RepoLabelKey    string `json:"repoLabelKey"`
-	TitleLabelKey   string `json:"titleLabelKey"`
-	GithubApi       struct {
-		BaseUrl string `json:"baseUrl"`
+	// GithubWebhook configures the receiver of github webhook deliveries.
+	GithubWebhook struct {
+		Path          string `json:"path"`
+		SecretName    string `json:"secretName"`
+		SecretKeyName string `json:"secretKeyName"`
 	}
 }
 
-//go:embed config.json
-var configFile []byte
+// The providers a host can be served by.
+const (
+	ProviderGithub = "github"
+	ProviderGitlab = "gitlab"
+	// ProviderGitea serves gitea and forgejo hosts, they share the same API.
+	ProviderGitea = "gitea"
+	// ProviderJira serves jira cloud and server sites, repo urls point at https://<host>/browse/<project key>.
+	ProviderJira = "jira"
+)
 
-func LoadConfig() (*Config, error) {
-	var config Config
-	if err := json.Unmarshal(configFile, &config); err != nil {
-		return nil, err
-	}
+// Host maps the web host found in repo urls to the provider and REST API serving it.
+type Host struct {
+	Host string `json:"host"`
+	// Provider is the forge behind the host, defaults to github.
+	Provider string `json:"provider"`
+	// ApiUrl is the REST API of the host, when empty it is https://<host>/api/v3 for GitHub Enterprise Server,
+	// https://<host>/api/v4 for gitlab, https://<host>/api/v1 for gitea and https://<host>/rest/api/2 for jira.
+	ApiUrl string `json:"apiUrl"`
+	// Jira holds the settings of jira hosts.
+	Jira JiraSettings `json:"jira"`
+}
 
-	return &config, nil
+// JiraSettings configures how issues are created and moved through the workflow of a jira site.
+type JiraSettings struct {
+	// IssueType is created when the GithubIssue sets none, defaults to Task.
+	IssueType string `json:"issueType"`
+	// CloseTransition and ReopenTransition name the workflow transitions (or their target statuses)
+	// closing and reopening an issue, they default to Done and To Do.
+	CloseTransition  string `json:"closeTransition"`
+	ReopenTransition string `json:"reopenTransition"`
 }
 
-func LoadEnvFile(envFile string) error {
-	err := godotenv.Load(dir(envFile))
-	if err != nil {
-		return err
+// APIURL returns the REST API base url of the host.
+func (h Host) APIURL() string {
+	if h.ApiUrl != "" {
+		return strings.TrimSuffix(h.ApiUrl, "/")
 	}
 
-	return nil
+	switch h.Provider {
+	case ProviderGitlab:
+		return fmt.Sprintf("https://%s/api/v4", h.Host)
+	case ProviderGitea:
+		return fmt.Sprintf("https://%s/api/v1", h.Host)
+	case ProviderJira:
+		return fmt.Sprintf("https://%s/rest/api/2", h.Host)
+	default:
+		return fmt.Sprintf("https://%s/api/v3", h.Host)
+	}
 }
 
-func dir(envFile string) string {
-	currentDir, err := os.Getwd()
-	if err != nil {
-		panic(err)
+// HostNames returns the configured hosts in config order.
+func (c *Config) HostNames() []string {
+	hostNames := make([]string, 0, len(c.Hosts))
+	for _, host := range c.Hosts {
+		hostNames = append(hostNames, host.Host)
 	}
 
-	print(currentDir)
+	return hostNames
+}
 
-	for {
-		goModPath := filepath.Join(currentDir, "go.mod")
-		if _, err := os.Stat(goModPath); err == nil {
-			break
+// FindHost returns the configuration of a host, hosts are matched case insensitively.
+func (c *Config) FindHost(hostName string) (Host, bool) {
+	for _, host := range c.Hosts {
+		if strings.EqualFold(host.Host, hostName) {
+			return host, true
 		}
+	}
+
+	return Host{}, false
+}
 
-		parent := filepath.Dir(currentDir)
-		if parent == currentDir {
-			panic(fmt.Errorf("go.mod not found"))
+//go:embed config.json
+var configFile []byte
+
+func LoadConfig() (*Config, error) {
+	var config Config
+	if err := json.Unmarshal(configFile, &config); err != nil {
+		return nil, err
+	}
+
+	for i := range config.Hosts {
+		config.Hosts[i].Host = strings.ToLower(config.Hosts[i].Host)
+		if config.Hosts[i].Provider == "" {
+			config.Hosts[i].Provider = ProviderGithub
 		}
-		currentDir = parent
 	}
 
-	return filepath.Join(currentDir, envFile)
+	return &config, nil
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
 	"time"
 
@@ -11,10 +13,12 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
@@ -23,6 +27,15 @@ import (
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 	"github.com/idoSharon1/githubIssue-operator/cmd/config"
 	"github.com/idoSharon1/githubIssue-operator/internal/controller"
+	"github.com/idoSharon1/githubIssue-operator/internal/githubwebhook"
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracing"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	trackercache "github.com/idoSharon1/githubIssue-operator/internal/tracker/cache"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/gitea"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/github"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/gitlab"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/jira"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -44,10 +57,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var githubWebhookAddr string
+	var githubWebhookSecretNamespace string
+	var maxConcurrentReconciles int
+	var otlpEndpoint string
+	var otlpInsecure bool
+	var traceSampleRatio float64
 
-	err := config.LoadEnvFile(".env")
+	loadedConfig, err := config.LoadConfig()
 	if err != nil {
-		setupLog.Error(err, "unable to load .env file")
+		setupLog.Error(err, "unable to load config")
 		os.Exit(1)
 	}
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
@@ -60,14 +79,34 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&githubWebhookAddr, "github-webhook-bind-address", "0", "The address the github webhook receiver binds to. "+
+		"Use the port :8082. If not set, it will be '0 in order to disable the receiver")
+	flag.StringVar(&githubWebhookSecretNamespace, "github-webhook-secret-namespace", os.Getenv("POD_NAMESPACE"),
+		"The namespace of the secret holding the github webhook secret, defaults to the namespace of the manager")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The number of GithubIssue objects reconciled in parallel")
 	opts := zap.Options{
 		Development: true,
 	}
+	flag.StringVar(&otlpEndpoint, "otlp-endpoint", "", "The host:port of the OTLP/HTTP collector the traces are exported to. "+
+		"Leave it empty to disable tracing.")
+	flag.BoolVar(&otlpInsecure, "otlp-insecure", false, "If set, the traces are exported over plain http.")
+	flag.Float64Var(&traceSampleRatio, "trace-sample-ratio", 1, "The ratio of the reconciles that are traced, between 0 and 1.")
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    otlpEndpoint,
+		Insecure:    otlpInsecure,
+		SampleRatio: traceSampleRatio,
+	})
+	if err != nil {
+		setupLog.Error(err, "unable to set up tracing")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -121,9 +160,87 @@ func main() {
 		os.Exit(1)
 	}
 
+	githubTracker := tracker.Hosts{}
+	githubApps := map[string]*github.AppTokenSource{}
+	for _, host := range loadedConfig.Hosts {
+		var hostTracker tracker.IssueTracker
+		transport := tracing.NewTransport(metrics.NewTransport(host.Host, nil))
+
+		switch host.Provider {
+		case config.ProviderGithub:
+			hostTracker = github.NewClient(github.Options{
+				BaseURL:   host.APIURL(),
+				Host:      host.Host,
+				PageSize:  loadedConfig.GithubApi.PerPage,
+				Token:     tracker.ContextToken,
+				Transport: transport,
+				ETagTTL:   time.Duration(loadedConfig.GithubApi.CacheTTLSeconds) * time.Second,
+			})
+			githubApps[host.Host] = github.NewAppTokenSource(host.APIURL(), transport)
+		case config.ProviderGitlab:
+			hostTracker = gitlab.NewClient(gitlab.Options{
+				BaseURL:   host.APIURL(),
+				PageSize:  loadedConfig.GithubApi.PerPage,
+				Token:     tracker.ContextToken,
+				Transport: transport,
+			})
+		case config.ProviderGitea:
+			hostTracker = gitea.NewClient(gitea.Options{
+				BaseURL:   host.APIURL(),
+				Token:     tracker.ContextToken,
+				Transport: transport,
+			})
+		case config.ProviderJira:
+			hostTracker = jira.NewClient(jira.Options{
+				BaseURL:          host.APIURL(),
+				Token:            tracker.ContextToken,
+				Transport:        transport,
+				IssueType:        host.Jira.IssueType,
+				CloseTransition:  host.Jira.CloseTransition,
+				ReopenTransition: host.Jira.ReopenTransition,
+			})
+		default:
+			setupLog.Error(fmt.Errorf("unknown provider %q", host.Provider), "unable to set up tracker", "host", host.Host)
+			os.Exit(1)
+		}
+
+		githubTracker[host.Host] = trackercache.New(hostTracker, trackercache.Options{
+			TTL:   time.Duration(loadedConfig.GithubApi.CacheTTLSeconds) * time.Second,
+			Token: tracker.ContextToken,
+		})
+	}
+
+	var webhookEvents chan event.GenericEvent
+	if githubWebhookAddr != "0" {
+		webhookEvents = make(chan event.GenericEvent)
+
+		if err = mgr.Add(&githubwebhook.Receiver{
+			Client: mgr.GetClient(),
+			Addr:   githubWebhookAddr,
+			Path:   loadedConfig.GithubWebhook.Path,
+			Secret: types.NamespacedName{
+				Namespace: githubWebhookSecretNamespace,
+				Name:      loadedConfig.GithubWebhook.SecretName,
+			},
+			SecretKey:    loadedConfig.GithubWebhook.SecretKeyName,
+			RepoLabelKey: loadedConfig.RepoLabelKey,
+			Events:       webhookEvents,
+			Invalidator:  githubTracker,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up github webhook receiver")
+			os.Exit(1)
+		}
+	}
+
 	if err = (&controller.GithubIssueReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Tracker:       githubTracker,
+		GithubApps:    githubApps,
+		WebhookEvents: webhookEvents,
+		Recorder:      mgr.GetEventRecorderFor("githubissue-controller"),
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GithubIssue")
 		os.Exit(1)
@@ -150,4 +267,11 @@ func main() {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		setupLog.Error(err, "unable to flush the pending traces")
+	}
 }
@@ -3,10 +3,11 @@ package controller
 import (
 	"context"
 	"fmt"
-	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -17,9 +18,16 @@ import (
 
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 	utils "github.com/idoSharon1/githubIssue-operator/internal/controller/utils"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracing"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/github"
 )
 
 func (r *GithubIssueReconciler) ensureSecret(githubIssueInstance *assignmentcoreiov1.GithubIssue, ctx context.Context, req ctrl.Request, githubSecretName string, githubSecretKeyName string) (*ctrl.Result, error) {
+	ctx, span := tracing.Start(ctx, "EnsureSecret",
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("k8s.secret.name", fmt.Sprintf("%s-%s", req.Name, githubSecretName)))
+	defer span.End()
+
 	logger := log.FromContext(ctx)
 
 	githubSecret := &corev1.Secret{}
@@ -34,10 +42,12 @@ func (r *GithubIssueReconciler) ensureSecret(githubIssueInstance *assignmentcore
 			if err != nil {
 				logger.Error(err, "Error at creating default secret, requeue reconcile function")
 				r.setCondition(ctx, githubIssueInstance, "AccessTokenSecretCreated", "False", "AccessTokenSecretCreated", "Error creatung github access token secret")
+				r.recordEvent(githubIssueInstance, corev1.EventTypeWarning, "SecretCreationFailed", fmt.Sprintf("Could not create the access token secret %s-%s: %s", req.Name, githubSecretName, err.Error()))
 				return &ctrl.Result{}, err
 			} else {
 				logger.Info("Successfully created default secret")
 				r.setCondition(ctx, githubIssueInstance, "AccessTokenSecretCreated", "True", "AccessTokenSecretCreated", "Successfully created github access token secret")
+				r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "SecretCreated", fmt.Sprintf("Created the access token secret %s-%s, put your access token in it", req.Name, githubSecretName))
 				return nil, nil
 			}
 		} else {
@@ -49,11 +59,13 @@ func (r *GithubIssueReconciler) ensureSecret(githubIssueInstance *assignmentcore
 	return nil, nil
 }
 
-func (r *GithubIssueReconciler) getValueFromSecretAndStoreEnv(ctx context.Context, req ctrl.Request, secretName string, keyName string, envName string) (*ctrl.Result, error) {
+// getAccessTokenFromSecret reads the credentials secret and returns the token the remote calls of this reconcile authenticate with.
+// App credentials are exchanged on the given host, an App installation belongs to a single host.
+func (r *GithubIssueReconciler) getAccessTokenFromSecret(ctx context.Context, secretName types.NamespacedName, keyName string, host string) (string, *ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
 	wantedSecret := &corev1.Secret{}
-	err := r.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: secretName}, wantedSecret)
+	err := r.Get(ctx, secretName, wantedSecret)
 
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -61,17 +73,45 @@ func (r *GithubIssueReconciler) getValueFromSecretAndStoreEnv(ctx context.Contex
 		} else {
 			logger.Error(err, fmt.Sprintf("Could not get secret when trying to find value for key %s in secret %s", keyName, secretName))
 		}
-		return &ctrl.Result{}, err
-	} else {
-		decodedString := string(wantedSecret.Data[keyName])
+		return "", &ctrl.Result{}, err
+	}
 
-		err := utils.SetEnvironmentVariable(envName, decodedString)
-		if err != nil {
-			logger.Error(err, "Could not save value to env variable")
-			return &ctrl.Result{}, err
+	token, err := r.tokenFromSecret(ctx, wantedSecret, keyName, host)
+
+	if err != nil {
+		logger.Error(err, fmt.Sprintf("Could not get an access token from secret %s", secretName))
+		return "", &ctrl.Result{}, err
+	}
+
+	return token, nil, nil
+}
+
+// tokenFromSecret returns the bearer token of a credentials secret, a secret with the app auth type holds
+// github App credentials that are exchanged for an installation token.
+func (r *GithubIssueReconciler) tokenFromSecret(ctx context.Context, secret *corev1.Secret, keyName string, host string) (string, error) {
+	authType := string(secret.Data[loadedConfig.AuthSecret.AuthTypeKeyName])
+
+	switch authType {
+	case "", AuthTypeToken:
+		token, ok := secret.Data[keyName]
+		if !ok {
+			return "", fmt.Errorf("secret %s has no key %q", secret.Name, keyName)
+		}
+
+		return string(token), nil
+	case AuthTypeApp:
+		githubApps, ok := r.GithubApps[host]
+		if !ok {
+			return "", fmt.Errorf("secret %s uses github App credentials but App authentication is not configured for %s", secret.Name, host)
 		}
 
-		return nil, nil
+		return githubApps.InstallationToken(ctx, github.AppCredentials{
+			AppID:          string(secret.Data[loadedConfig.AuthSecret.AppIDKeyName]),
+			InstallationID: string(secret.Data[loadedConfig.AuthSecret.InstallationIDKeyName]),
+			PrivateKey:     secret.Data[loadedConfig.AuthSecret.PrivateKeyKeyName],
+		})
+	default:
+		return "", fmt.Errorf("secret %s has unknown auth type %q", secret.Name, authType)
 	}
 }
 
@@ -80,15 +120,15 @@ func (r *GithubIssueReconciler) isFinalizerExist(githubIssueInstance *assignment
 }
 
 func (r *GithubIssueReconciler) isHelpLabelsExist(githubIssueInstance *assignmentcoreiov1.GithubIssue) bool {
-	if githubIssueInstance.GetLabels()[loadedConfig.TitleLabelKey] != "" && githubIssueInstance.GetLabels()[loadedConfig.RepoLabelKey] != "" {
-		print("asfasf")
-	}
-
 	return githubIssueInstance.GetLabels()[loadedConfig.TitleLabelKey] != "" && githubIssueInstance.GetLabels()[loadedConfig.RepoLabelKey] != ""
 }
 
 func (r *GithubIssueReconciler) addHelperLabels(githubIssueInstance *assignmentcoreiov1.GithubIssue, ctx context.Context) error {
-	githubIssueInstance.Labels[loadedConfig.TitleLabelKey] = githubIssueInstance.Spec.Title
+	if githubIssueInstance.Labels == nil {
+		githubIssueInstance.Labels = map[string]string{}
+	}
+
+	githubIssueInstance.Labels[loadedConfig.TitleLabelKey] = r.changeTitleToLabelFormat(githubIssueInstance)
 	githubIssueInstance.Labels[loadedConfig.RepoLabelKey] = r.changeRepoToLabelFormat(githubIssueInstance)
 
 	err := r.Update(ctx, githubIssueInstance)
@@ -103,7 +143,12 @@ func (r *GithubIssueReconciler) addHelperLabels(githubIssueInstance *assignmentc
 func (r *GithubIssueReconciler) changeRepoToLabelFormat(githubIssueInstance *assignmentcoreiov1.GithubIssue) string {
 	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
 
-	return fmt.Sprintf("%s.%s", owner, repo)
+	return utils.RepoLabelValue(owner, repo)
+}
+
+// changeTitleToLabelFormat turns the spec title into a valid label value, the title label follows renames of the issue.
+func (r *GithubIssueReconciler) changeTitleToLabelFormat(githubIssueInstance *assignmentcoreiov1.GithubIssue) string {
+	return utils.ToLabelValue(githubIssueInstance.Spec.Title)
 }
 
 func (r *GithubIssueReconciler) addFinalizersIfNeeded(githubIssueInstance *assignmentcoreiov1.GithubIssue, ctx context.Context) {
@@ -132,7 +177,7 @@ func (r *GithubIssueReconciler) addHelperLabelsIfNeeded(githubIssueInstance *ass
 
 	if (!r.isHelpLabelsExist(githubIssueInstance)) ||
 		(githubIssueInstance.GetLabels()[loadedConfig.RepoLabelKey] != r.changeRepoToLabelFormat(githubIssueInstance) ||
-			githubIssueInstance.GetLabels()[loadedConfig.TitleLabelKey] != githubIssueInstance.Spec.Title) {
+			githubIssueInstance.GetLabels()[loadedConfig.TitleLabelKey] != r.changeTitleToLabelFormat(githubIssueInstance)) {
 		logger.Info("Adding helper labels")
 		err := r.addHelperLabels(githubIssueInstance, ctx)
 
@@ -158,48 +203,55 @@ func (r *GithubIssueReconciler) removeFinalizer(githubIssueInstance *assignmentc
 	return nil
 }
 
-func (r *GithubIssueReconciler) containsCondition(githubIssueInstance *assignmentcoreiov1.GithubIssue, conditionValue string, newStatus metav1.ConditionStatus) bool {
-	output := false
+func (r *GithubIssueReconciler) setConditionIssueIsOpen(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus) {
+	const CONDITION_ISSUE_IS_OPEN_MESSAGE = "Issue opened successfully on github"
+	const CONDITION_ISSUE_IS_OPEN_REASON = "IssueOpen"
+	CONDITION_ISSUE_IS_OPEN_STATUS := status
+	const CONDITION_ISSUE_IS_OPEN_TYPE = "IssueOpen"
 
-	for _, condition := range githubIssueInstance.Status.Conditons {
-		if condition.Reason == conditionValue && condition.Status == newStatus {
-			output = true
-		}
-	}
-	return output
+	r.setCondition(ctx, githubIssueInstance, CONDITION_ISSUE_IS_OPEN_TYPE, CONDITION_ISSUE_IS_OPEN_STATUS, CONDITION_ISSUE_IS_OPEN_REASON, CONDITION_ISSUE_IS_OPEN_MESSAGE)
 }
 
-func (r *GithubIssueReconciler) appendCondition(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue,
-	typeName string, status metav1.ConditionStatus, reason string, message string) error {
+func (r *GithubIssueReconciler) setConditionHasOpenPR(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_HAS_OPEN_PR_REASON = "HasOpenPR"
+	const CONDITION_HAS_OPEN_PR_TYPE = "HasOpenPR"
 
-	log := log.FromContext(ctx)
-	time := metav1.Time{Time: time.Now()}
-	condition := metav1.Condition{Type: typeName, Status: status, Reason: reason, Message: message, LastTransitionTime: time}
-	githubIssueInstance.Status.Conditons = append(githubIssueInstance.Status.Conditons, condition)
+	r.setCondition(ctx, githubIssueInstance, CONDITION_HAS_OPEN_PR_TYPE, status, CONDITION_HAS_OPEN_PR_REASON, message)
+}
 
-	err := r.Client.Status().Update(ctx, githubIssueInstance)
-	if err != nil {
-		log.Error(err, "GithubIssue resource status update failed.")
-	}
-	return nil
+func (r *GithubIssueReconciler) setConditionPRMerged(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_PR_MERGED_REASON = "PRMerged"
+	const CONDITION_PR_MERGED_TYPE = "PRMerged"
+
+	r.setCondition(ctx, githubIssueInstance, CONDITION_PR_MERGED_TYPE, status, CONDITION_PR_MERGED_REASON, message)
 }
 
-func (r *GithubIssueReconciler) setConditionIssueIsOpen(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus) {
-	const CONDITION_ISSUE_IS_OPEN_MESSAGE = "Issue opened successfully on github"
-	const CONDITION_ISSUE_IS_OPEN_REASON = "IssueOpen"
-	CONDITION_ISSUE_IS_OPEN_STATUS := status
-	const CONDITION_ISSUE_IS_OPEN_TYPE = "IssueOpen"
+func (r *GithubIssueReconciler) setConditionIssueMetadataSynced(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_ISSUE_METADATA_SYNCED_REASON = "IssueMetadataSynced"
+	const CONDITION_ISSUE_METADATA_SYNCED_TYPE = "IssueMetadataSynced"
 
-	r.setCondition(ctx, githubIssueInstance, CONDITION_ISSUE_IS_OPEN_TYPE, CONDITION_ISSUE_IS_OPEN_STATUS, CONDITION_ISSUE_IS_OPEN_REASON, CONDITION_ISSUE_IS_OPEN_MESSAGE)
+	r.setCondition(ctx, githubIssueInstance, CONDITION_ISSUE_METADATA_SYNCED_TYPE, status, CONDITION_ISSUE_METADATA_SYNCED_REASON, message)
+}
+
+func (r *GithubIssueReconciler) setConditionRemoteDrift(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_REMOTE_DRIFT_REASON = "RemoteDrift"
+	const CONDITION_REMOTE_DRIFT_TYPE = "RemoteDrift"
+
+	r.setCondition(ctx, githubIssueInstance, CONDITION_REMOTE_DRIFT_TYPE, status, CONDITION_REMOTE_DRIFT_REASON, message)
+}
+
+func (r *GithubIssueReconciler) setConditionRateLimited(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_RATE_LIMITED_REASON = "RateLimited"
+	const CONDITION_RATE_LIMITED_TYPE = "RateLimited"
+
+	r.setCondition(ctx, githubIssueInstance, CONDITION_RATE_LIMITED_TYPE, status, CONDITION_RATE_LIMITED_REASON, message)
 }
 
-func (r *GithubIssueReconciler) setConditionIssueHasPullRequest(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus) {
-	const CONDITION_ISSUE_HAS_PR_MESSAGE = "Issue has pull request"
-	const CONDITION_ISSUE_HAS_PR_REASON = "IssueHasPR"
-	CONDITION_ISSUE_HAS_PR_STATUS := status
-	const CONDITION_ISSUE_HAS_PR_TYPE = "IssueHasPR"
+func (r *GithubIssueReconciler) setConditionCredentialsResolved(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, status metav1.ConditionStatus, message string) {
+	const CONDITION_CREDENTIALS_RESOLVED_REASON = "CredentialsResolved"
+	const CONDITION_CREDENTIALS_RESOLVED_TYPE = "CredentialsResolved"
 
-	r.setCondition(ctx, githubIssueInstance, CONDITION_ISSUE_HAS_PR_TYPE, CONDITION_ISSUE_HAS_PR_STATUS, CONDITION_ISSUE_HAS_PR_REASON, CONDITION_ISSUE_HAS_PR_MESSAGE)
+	r.setCondition(ctx, githubIssueInstance, CONDITION_CREDENTIALS_RESOLVED_TYPE, status, CONDITION_CREDENTIALS_RESOLVED_REASON, message)
 }
 
 func (r *GithubIssueReconciler) updateConditionToAllOldRelevantObjects(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) {
@@ -210,7 +262,7 @@ func (r *GithubIssueReconciler) updateConditionToAllOldRelevantObjects(ctx conte
 
 		labelSelector := labels.Set{
 			loadedConfig.RepoLabelKey:  r.changeRepoToLabelFormat(githubIssueInstance),
-			loadedConfig.TitleLabelKey: githubIssueInstance.Spec.Title,
+			loadedConfig.TitleLabelKey: r.changeTitleToLabelFormat(githubIssueInstance),
 		}
 		selector := labels.SelectorFromSet(labelSelector)
 
@@ -226,6 +278,10 @@ func (r *GithubIssueReconciler) updateConditionToAllOldRelevantObjects(ctx conte
 
 		logger.Info("Update all relevant githubIssues in the cluster with their correspond status")
 		for _, currentIssue := range allIssues.Items {
+			if currentIssue.UID == githubIssueInstance.UID {
+				continue
+			}
+
 			if currentIssue.Spec.Description != githubIssueInstance.Spec.Description {
 				r.setCondition(ctx, &currentIssue, "IssueDescriptionUnaffected", "True", "IssueDescriptionUnaffected", "Issue description not longer affected by this githubIssue")
 			} else {
@@ -237,14 +293,126 @@ func (r *GithubIssueReconciler) updateConditionToAllOldRelevantObjects(ctx conte
 	}
 }
 
+// setCondition keeps a single condition per type, the status is only written when the condition changed.
 func (r *GithubIssueReconciler) setCondition(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, typeName string, status metav1.ConditionStatus, reason string, message string) {
 	logger := log.FromContext(ctx)
 
-	if !r.containsCondition(githubIssueInstance, reason, status) {
-		err := r.appendCondition(ctx, githubIssueInstance, typeName, status, reason, message)
+	changed := meta.SetStatusCondition(&githubIssueInstance.Status.Conditons, metav1.Condition{
+		Type:               typeName,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: githubIssueInstance.Generation,
+	})
 
-		if err != nil {
-			logger.Error(err, "Could not update status")
+	if !changed {
+		return
+	}
+
+	err := r.updateStatus(ctx, githubIssueInstance)
+	if err != nil {
+		logger.Error(err, "GithubIssue resource status update failed.")
+	}
+}
+
+// recordEvent emits an event on the object, reconcilers without a Recorder drop it.
+func (r *GithubIssueReconciler) recordEvent(githubIssueInstance *assignmentcoreiov1.GithubIssue, eventType string, reason string, message string) {
+	if r.Recorder == nil {
+		return
+	}
+
+	r.Recorder.Event(githubIssueInstance, eventType, reason, message)
+}
+
+// readyBlockers are the conditions keeping an object from being Ready while they have the given status.
+var readyBlockers = []struct {
+	conditionType string
+	status        metav1.ConditionStatus
+}{
+	{"CredentialsResolved", metav1.ConditionFalse},
+	{"UserUpdatedHisAccessTokenInSecretAndExistingRepo", metav1.ConditionFalse},
+	{"IssueMetadataSynced", metav1.ConditionFalse},
+	{"RateLimited", metav1.ConditionTrue},
+}
+
+// updateReadyCondition summarizes the outcome of a reconcile in the Ready condition and the phase,
+// objects released by their finalizer are left alone since they are about to disappear.
+func (r *GithubIssueReconciler) updateReadyCondition(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, reconcileErr error) {
+	logger := log.FromContext(ctx)
+
+	isDeleting := !githubIssueInstance.ObjectMeta.DeletionTimestamp.IsZero()
+	if isDeleting && !r.isFinalizerExist(githubIssueInstance) {
+		return
+	}
+
+	status, reason, message := metav1.ConditionTrue, "Reconciled", "Remote issue is in sync with the spec"
+	phase := assignmentcoreiov1.IssuePhaseOpen
+	if githubIssueInstance.Status.State == string(assignmentcoreiov1.IssueStateClosed) {
+		phase = assignmentcoreiov1.IssuePhaseClosed
+	}
+
+	hasRemoteIssue := githubIssueInstance.Status.IssueNumber != 0 || githubIssueInstance.Status.IssueKey != ""
+	if !hasRemoteIssue {
+		phase = assignmentcoreiov1.IssuePhasePending
+	}
+
+	var blocker *metav1.Condition
+	for _, readyBlocker := range readyBlockers {
+		condition := meta.FindStatusCondition(githubIssueInstance.Status.Conditons, readyBlocker.conditionType)
+		if condition != nil && condition.Status == readyBlocker.status {
+			blocker = condition
+			break
 		}
 	}
+
+	switch {
+	case isDeleting:
+		status, reason, message = metav1.ConditionFalse, "Deleting", fmt.Sprintf("Applying deletion policy %s", githubIssueInstance.Spec.DeletionPolicy)
+		phase = assignmentcoreiov1.IssuePhaseTerminating
+	case blocker != nil:
+		status, reason, message = metav1.ConditionFalse, blocker.Reason, blocker.Message
+		if blocker.Type != "RateLimited" {
+			phase = assignmentcoreiov1.IssuePhaseFailed
+		}
+	case reconcileErr != nil:
+		status, reason, message = metav1.ConditionFalse, "ReconcileFailed", reconcileErr.Error()
+		phase = assignmentcoreiov1.IssuePhaseFailed
+	case !hasRemoteIssue:
+		status, reason, message = metav1.ConditionFalse, "RemoteIssueMissing", "Remote issue has not been opened yet"
+	}
+
+	changed := meta.SetStatusCondition(&githubIssueInstance.Status.Conditons, metav1.Condition{
+		Type:               "Ready",
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: githubIssueInstance.Generation,
+	})
+
+	if githubIssueInstance.Status.Phase != phase || githubIssueInstance.Status.ObservedGeneration != githubIssueInstance.Generation {
+		githubIssueInstance.Status.Phase = phase
+		githubIssueInstance.Status.ObservedGeneration = githubIssueInstance.Generation
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	err := r.updateStatus(ctx, githubIssueInstance)
+	if err != nil {
+		logger.Error(err, "Could not update the Ready condition")
+	}
+}
+
+// updateStatus writes the status of the object in a span of its own so status writes show up in the reconcile trace.
+func (r *GithubIssueReconciler) updateStatus(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) error {
+	ctx, span := tracing.Start(ctx, "UpdateStatus",
+		attribute.String("k8s.namespace.name", githubIssueInstance.Namespace),
+		attribute.String("githubissue.name", githubIssueInstance.Name))
+
+	err := r.Client.Status().Update(ctx, githubIssueInstance)
+
+	tracing.End(span, err)
+	return err
 }
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
+	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
+)
+
+// credentials locates the secret key an object authenticates with.
+type credentials struct {
+	secret types.NamespacedName
+	key    string
+	// binding is the GithubCredentialBinding the secret was routed through, empty otherwise.
+	binding string
+}
+
+func (c *credentials) String() string {
+	if c.binding != "" {
+		return fmt.Sprintf("key %s of secret %s (GithubCredentialBinding %s)", c.key, c.secret, c.binding)
+	}
+
+	return fmt.Sprintf("key %s of secret %s", c.key, c.secret)
+}
+
+// resolveCredentials picks the credentials of an object: its credentialsRef, then the most specific matching
+// GithubCredentialBinding, and last the per object secret (created only when opted in).
+func (r *GithubIssueReconciler) resolveCredentials(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, loadedConfig *config.Config) (*credentials, error) {
+	credentialsRef := githubIssueInstance.Spec.CredentialsRef
+
+	if credentialsRef != nil {
+		return &credentials{
+			secret: types.NamespacedName{Namespace: githubIssueInstance.Namespace, Name: credentialsRef.Name},
+			key:    r.secretKeyOrDefault(credentialsRef.Key, loadedConfig),
+		}, nil
+	}
+
+	if !githubIssueInstance.Spec.CreateCredentialsSecret {
+		binding, err := r.matchCredentialBinding(ctx, githubIssueInstance)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if binding != nil {
+			return &credentials{
+				secret:  types.NamespacedName{Namespace: binding.Spec.SecretRef.Namespace, Name: binding.Spec.SecretRef.Name},
+				key:     r.secretKeyOrDefault(binding.Spec.SecretRef.Key, loadedConfig),
+				binding: binding.Name,
+			}, nil
+		}
+	}
+
+	return &credentials{
+		secret: types.NamespacedName{Namespace: githubIssueInstance.Namespace, Name: fmt.Sprintf("%s-%s", githubIssueInstance.Name, loadedConfig.AuthSecret.GithubSecretName)},
+		key:    loadedConfig.AuthSecret.GithubSecretKeyName,
+	}, nil
+}
+
+func (r *GithubIssueReconciler) secretKeyOrDefault(key string, loadedConfig *config.Config) string {
+	if key == "" {
+		return loadedConfig.AuthSecret.GithubSecretKeyName
+	}
+
+	return key
+}
+
+// matchCredentialBinding returns the binding of the repo's host whose repository pattern matches the repo most specifically,
+// nil when no binding applies. Ties go to bindings with a namespace selector, then to the first name.
+func (r *GithubIssueReconciler) matchCredentialBinding(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (*assignmentcoreiov1.GithubCredentialBinding, error) {
+	logger := log.FromContext(ctx)
+
+	bindingList := &assignmentcoreiov1.GithubCredentialBindingList{}
+	err := r.List(ctx, bindingList)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bindingList.Items) == 0 {
+		return nil, nil
+	}
+
+	namespace := &corev1.Namespace{}
+	err = r.Get(ctx, types.NamespacedName{Name: githubIssueInstance.Namespace}, namespace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	repository := r.repository(githubIssueInstance)
+	repo := strings.ToLower(repository.String())
+
+	type candidate struct {
+		binding     *assignmentcoreiov1.GithubCredentialBinding
+		specificity int
+	}
+
+	var candidates []candidate
+	for i := range bindingList.Items {
+		binding := &bindingList.Items[i]
+
+		if !strings.EqualFold(bindingHost(binding), repository.Host) {
+			continue
+		}
+
+		if binding.Spec.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(binding.Spec.NamespaceSelector)
+
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Ignoring GithubCredentialBinding %s with an invalid namespace selector", binding.Name))
+				continue
+			}
+
+			if !selector.Matches(labels.Set(namespace.Labels)) {
+				continue
+			}
+		}
+
+		specificity := -1
+		for _, pattern := range binding.Spec.Repositories {
+			matched, err := path.Match(strings.ToLower(pattern), repo)
+
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Ignoring invalid repository pattern %q of GithubCredentialBinding %s", pattern, binding.Name))
+				continue
+			}
+
+			if matched && patternSpecificity(pattern) > specificity {
+				specificity = patternSpecificity(pattern)
+			}
+		}
+
+		if specificity >= 0 {
+			candidates = append(candidates, candidate{binding: binding, specificity: specificity})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].specificity != candidates[j].specificity {
+			return candidates[i].specificity > candidates[j].specificity
+		}
+
+		iSelects := candidates[i].binding.Spec.NamespaceSelector != nil
+		jSelects := candidates[j].binding.Spec.NamespaceSelector != nil
+		if iSelects != jSelects {
+			return iSelects
+		}
+
+		return candidates[i].binding.Name < candidates[j].binding.Name
+	})
+
+	return candidates[0].binding, nil
+}
+
+// bindingHost is the host the binding's repositories live on.
+func bindingHost(binding *assignmentcoreiov1.GithubCredentialBinding) string {
+	if binding.Spec.Host == "" {
+		return assignmentcoreiov1.DefaultGithubHost
+	}
+
+	return binding.Spec.Host
+}
+
+// patternSpecificity ranks repository patterns, an exact owner/name beats every glob and
+// globs are ranked by the number of literal characters they pin down.
+func patternSpecificity(pattern string) int {
+	if !strings.ContainsAny(pattern, `*?[\`) {
+		return math.MaxInt
+	}
+
+	literals := 0
+	for _, character := range pattern {
+		if !strings.ContainsRune(`*?[]\`, character) {
+			literals++
+		}
+	}
+
+	return literals
+}
@@ -4,11 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"strconv"
 	"strings"
-
-	"github.com/go-resty/resty/v2"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,10 +16,11 @@ import (
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
 	"github.com/idoSharon1/githubIssue-operator/internal/controller/utils"
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
 )
 
 var loadedConfig, _ = config.LoadConfig()
-var restyClient = resty.New()
 
 func (r *GithubIssueReconciler) GithubDefaultAuthSecret(githubIssueInstance *assignmentcoreiov1.GithubIssue, namespacedName types.NamespacedName, wantedTokenKey string) *corev1.Secret {
 	defaultSecret := &corev1.Secret{
@@ -41,84 +39,253 @@ func (r *GithubIssueReconciler) GithubDefaultAuthSecret(githubIssueInstance *ass
 	return defaultSecret
 }
 
-func (r *GithubIssueReconciler) openIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) error {
+func (r *GithubIssueReconciler) openIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (*tracker.Issue, error) {
 	logger := log.FromContext(ctx)
-	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
 
-	res, err := restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", os.Getenv(loadedConfig.EnvName))).
-		SetBody(map[string]interface{}{
-			"owner": owner,
-			"repo":  repo,
-			"title": githubIssueInstance.Spec.Title,
-			"body":  githubIssueInstance.Spec.Description,
-		}).
-		Post(fmt.Sprintf("https://%s/repos/%s/%s/issues", loadedConfig.GithubApi.BaseUrl, owner, repo))
-
-	if res.StatusCode() == 401 || res.StatusCode() == 404 {
-		logger.Error(err, "Bad credentials, please update the access token in your secret")
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Please update your access token inside the secret we created for your object and ensure your repo is correct")
-		return errors.New("bad credentials")
-	} else {
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "True", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Your object repo is correct with corresponding access token")
+	request := tracker.IssueRequest{
+		Title:     tracker.StringPtr(githubIssueInstance.Spec.Title),
+		Body:      tracker.StringPtr(githubIssueInstance.Spec.Description),
+		Labels:    githubIssueInstance.Spec.Labels,
+		Assignees: githubIssueInstance.Spec.Assignees,
 	}
 
+	if githubIssueInstance.Spec.Milestone != "" {
+		request.Milestone = tracker.StringPtr(githubIssueInstance.Spec.Milestone)
+	}
+
+	if githubIssueInstance.Spec.IssueType != "" {
+		request.Type = tracker.StringPtr(githubIssueInstance.Spec.IssueType)
+	}
+
+	remoteIssue, err := r.Tracker.CreateIssue(ctx, r.repository(githubIssueInstance), request)
+	r.setConditionAccessToken(ctx, githubIssueInstance, err)
+
 	if err != nil {
 		logger.Error(err, "Could not create new issue at this point")
-		return err
+		r.recordEvent(githubIssueInstance, corev1.EventTypeWarning, "IssueOpenFailed", fmt.Sprintf("Could not open the remote issue: %s", err.Error()))
+		return nil, err
 	}
 
-	return nil
+	metrics.IssuesCreated.WithLabelValues(metrics.RepoLabel(r.repository(githubIssueInstance))).Inc()
+	logger.Info(fmt.Sprintf("Opened issue #%d -> %s", remoteIssue.Number, remoteIssue.HTMLURL))
+	r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "IssueOpened", fmt.Sprintf("Opened issue #%d: %s", remoteIssue.Number, remoteIssue.HTMLURL))
+	return remoteIssue, nil
 }
 
-func (r *GithubIssueReconciler) findRelevantIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (utils.GithubReponseWantedProperties, error) {
+// findRelevantIssue returns the remote issue managed by this object or nil if it was not opened yet.
+// Once the issue number is stored in the status the issue is fetched directly, objects without one
+// (created before the number was tracked) fall back to a one time lookup by title.
+func (r *GithubIssueReconciler) findRelevantIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (*tracker.Issue, error) {
 	logger := log.FromContext(ctx)
+
+	if githubIssueInstance.Status.IssueNumber != 0 {
+		remoteIssue, err := r.Tracker.GetIssue(ctx, r.repository(githubIssueInstance), githubIssueInstance.Status.IssueNumber)
+		r.setConditionAccessToken(ctx, githubIssueInstance, err)
+
+		if err != nil {
+			logger.Error(err, fmt.Sprintf("Could not get remote issue #%d", githubIssueInstance.Status.IssueNumber))
+			return nil, err
+		}
+
+		return remoteIssue, nil
+	}
+
 	allRepoIssues, err := r.getAllRepoIssues(ctx, githubIssueInstance)
-	var foundIssue utils.GithubReponseWantedProperties
 
 	if err != nil {
-		return foundIssue, err
+		return nil, err
 	}
 
-	for _, currentRepoIssue := range allRepoIssues {
-		if currentRepoIssue.Title == githubIssueInstance.Spec.Title {
-			logger.Info("Found the wanted issue")
-			foundIssue = currentRepoIssue
-			break
+	// Prefer an open issue, a closed one with the same title is still ours and must not be opened again.
+	var foundIssue *tracker.Issue
+	for i := range allRepoIssues {
+		if allRepoIssues[i].Title == githubIssueInstance.Spec.Title {
+			if foundIssue == nil || (foundIssue.State != "open" && allRepoIssues[i].State == "open") {
+				foundIssue = &allRepoIssues[i]
+			}
 		}
 	}
 
+	if foundIssue != nil {
+		logger.Info(fmt.Sprintf("Found the wanted issue #%d", foundIssue.Number))
+	}
+
 	return foundIssue, nil
 }
 
-func (r *GithubIssueReconciler) updateIssueOnRepoIfNeeded(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (bool, error) {
+// updateIssueOnRepoIfNeeded pushes spec title, description and state changes to the remote issue,
+// the issue is identified by its number so a title change renames it instead of opening a new one.
+func (r *GithubIssueReconciler) updateIssueOnRepoIfNeeded(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) (*tracker.Issue, bool, error) {
 	logger := log.FromContext(ctx)
 	isUpdated := false
 
-	issueOnRepo, err := r.findRelevantIssue(ctx, githubIssueInstance)
+	request, drift := r.issueContentDrift(githubIssueInstance, issueOnRepo)
+
+	if len(drift) == 0 {
+		logger.Info("No need to update remote issue")
+		return issueOnRepo, isUpdated, nil
+	}
+
+	logger.Info(fmt.Sprintf("Trying to update issue #%d: %s", issueOnRepo.Number, strings.Join(drift, ", ")))
+	updatedIssue, err := r.updateIssue(ctx, githubIssueInstance, issueOnRepo, request)
 
 	if err != nil {
-		return isUpdated, err
+		return issueOnRepo, isUpdated, err
 	}
 
-	if issueOnRepo.Description != githubIssueInstance.Spec.Description {
-		logger.Info(fmt.Sprintf("Trying to update issue %s value to %s", githubIssueInstance.Spec.Title, githubIssueInstance.Spec.Description))
-		err := r.updateIssue(ctx, githubIssueInstance, issueOnRepo, utils.UpdatedValue{Key: "body", Value: githubIssueInstance.Spec.Description})
+	logger.Info("Updated Successfully")
+	r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "IssueUpdated", fmt.Sprintf("Updated issue #%d: %s", issueOnRepo.Number, strings.Join(drift, ", ")))
+	isUpdated = true
 
-		if err != nil {
-			return isUpdated, err
-		}
+	return updatedIssue, isUpdated, nil
+}
+
+// issueContentDrift compares title, description and state and returns the request fixing them with a readable description of each difference.
+func (r *GithubIssueReconciler) issueContentDrift(githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) (tracker.IssueRequest, []string) {
+	var request tracker.IssueRequest
+	var drift []string
+
+	if issueOnRepo.Title != githubIssueInstance.Spec.Title {
+		request.Title = tracker.StringPtr(githubIssueInstance.Spec.Title)
+		drift = append(drift, fmt.Sprintf("title (%q -> %q)", issueOnRepo.Title, githubIssueInstance.Spec.Title))
+	}
+
+	if issueOnRepo.Body != githubIssueInstance.Spec.Description {
+		request.Body = tracker.StringPtr(githubIssueInstance.Spec.Description)
+		drift = append(drift, "body")
+	}
+
+	wantedState := r.wantedState(githubIssueInstance)
+	if issueOnRepo.State != wantedState {
+		request.State = tracker.StringPtr(wantedState)
+		drift = append(drift, fmt.Sprintf("state (%s -> %s)", issueOnRepo.State, wantedState))
+	}
+
+	if wantedState == string(assignmentcoreiov1.IssueStateClosed) && githubIssueInstance.Spec.StateReason != "" &&
+		issueOnRepo.StateReason != string(githubIssueInstance.Spec.StateReason) {
+		request.State = tracker.StringPtr(wantedState)
+		request.StateReason = tracker.StringPtr(string(githubIssueInstance.Spec.StateReason))
+		drift = append(drift, fmt.Sprintf("state reason (%s -> %s)", issueOnRepo.StateReason, githubIssueInstance.Spec.StateReason))
+	}
+
+	return request, drift
+}
 
-		logger.Info("Updated Successfully")
-		isUpdated = true
+// remoteDrift lists every managed field where the remote issue differs from the spec.
+func (r *GithubIssueReconciler) remoteDrift(githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) []string {
+	_, contentDrift := r.issueContentDrift(githubIssueInstance, issueOnRepo)
+	_, metadataDrift := r.issueMetadataDrift(githubIssueInstance, issueOnRepo)
+
+	return append(contentDrift, metadataDrift...)
+}
+
+// syncPolicy returns the spec sync policy, objects created before the field existed are enforced.
+func (r *GithubIssueReconciler) syncPolicy(githubIssueInstance *assignmentcoreiov1.GithubIssue) assignmentcoreiov1.SyncPolicy {
+	if githubIssueInstance.Spec.SyncPolicy == "" {
+		return assignmentcoreiov1.SyncPolicyEnforce
+	}
+
+	return githubIssueInstance.Spec.SyncPolicy
+}
+
+// wantedState returns the spec state, objects created before the field existed are kept open.
+func (r *GithubIssueReconciler) wantedState(githubIssueInstance *assignmentcoreiov1.GithubIssue) string {
+	if githubIssueInstance.Spec.State == "" {
+		return string(assignmentcoreiov1.IssueStateOpen)
+	}
+
+	return string(githubIssueInstance.Spec.State)
+}
+
+// updateIssueMetadataIfNeeded makes the remote labels, assignees and milestone match the spec and reports drift in the IssueMetadataSynced condition.
+func (r *GithubIssueReconciler) updateIssueMetadataIfNeeded(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) (*tracker.Issue, error) {
+	logger := log.FromContext(ctx)
+
+	request, drift := r.issueMetadataDrift(githubIssueInstance, issueOnRepo)
+
+	if len(drift) == 0 {
+		r.setConditionIssueMetadataSynced(ctx, githubIssueInstance, "True", "Remote labels, assignees and milestone match the spec")
+		return issueOnRepo, nil
+	}
+
+	logger.Info(fmt.Sprintf("Remote issue #%d drifted from spec: %s", issueOnRepo.Number, strings.Join(drift, ", ")))
+	updatedIssue, err := r.updateIssue(ctx, githubIssueInstance, issueOnRepo, request)
+
+	if err != nil {
+		r.setConditionIssueMetadataSynced(ctx, githubIssueInstance, "False", fmt.Sprintf("Could not correct drift (%s): %s", strings.Join(drift, ", "), err.Error()))
+		return issueOnRepo, err
+	}
+
+	r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "IssueUpdated", fmt.Sprintf("Updated issue #%d: %s", issueOnRepo.Number, strings.Join(drift, ", ")))
+
+	// github silently ignores assignees without access to the repo, so check again what was actually applied.
+	if _, remainingDrift := r.issueMetadataDrift(githubIssueInstance, updatedIssue); len(remainingDrift) != 0 {
+		r.setConditionIssueMetadataSynced(ctx, githubIssueInstance, "False", fmt.Sprintf("Remote did not accept: %s", strings.Join(remainingDrift, ", ")))
 	} else {
-		logger.Info("No need to update remote issue")
+		r.setConditionIssueMetadataSynced(ctx, githubIssueInstance, "True", fmt.Sprintf("Corrected drift: %s", strings.Join(drift, ", ")))
 	}
 
-	return isUpdated, nil
+	return updatedIssue, nil
+}
+
+// issueMetadataDrift compares the managed metadata fields and returns the request fixing them with a readable description of each difference.
+func (r *GithubIssueReconciler) issueMetadataDrift(githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) (tracker.IssueRequest, []string) {
+	var request tracker.IssueRequest
+	var drift []string
+
+	if len(githubIssueInstance.Spec.Labels) != 0 {
+		added, removed := utils.DiffStrings(githubIssueInstance.Spec.Labels, issueOnRepo.Labels)
+		if len(added) != 0 || len(removed) != 0 {
+			request.Labels = githubIssueInstance.Spec.Labels
+			drift = append(drift, fmt.Sprintf("labels (+%v -%v)", added, removed))
+		}
+	}
+
+	if len(githubIssueInstance.Spec.Assignees) != 0 {
+		added, removed := utils.DiffStrings(githubIssueInstance.Spec.Assignees, issueOnRepo.Assignees)
+		if len(added) != 0 || len(removed) != 0 {
+			request.Assignees = githubIssueInstance.Spec.Assignees
+			drift = append(drift, fmt.Sprintf("assignees (+%v -%v)", added, removed))
+		}
+	}
+
+	if githubIssueInstance.Spec.Milestone != "" && githubIssueInstance.Spec.Milestone != issueOnRepo.Milestone {
+		request.Milestone = tracker.StringPtr(githubIssueInstance.Spec.Milestone)
+		drift = append(drift, fmt.Sprintf("milestone (%q -> %q)", issueOnRepo.Milestone, githubIssueInstance.Spec.Milestone))
+	}
+
+	return request, drift
+}
+
+// finalizeIssue applies the deletion policy of the object to its remote issue.
+func (r *GithubIssueReconciler) finalizeIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) error {
+	logger := log.FromContext(ctx)
+
+	if r.syncPolicy(githubIssueInstance) == assignmentcoreiov1.SyncPolicyObserve {
+		logger.Info("Sync policy is Observe, leaving remote issue as is")
+		return nil
+	}
+
+	switch githubIssueInstance.Spec.DeletionPolicy {
+	case assignmentcoreiov1.DeletionPolicyOrphan:
+		logger.Info("Deletion policy is Orphan, leaving remote issue as is")
+		return nil
+	case assignmentcoreiov1.DeletionPolicyLock:
+		issueOnRepo, err := r.closeIssue(ctx, githubIssueInstance)
+
+		if err != nil || issueOnRepo == nil {
+			return err
+		}
+
+		return r.lockIssue(ctx, githubIssueInstance, issueOnRepo)
+	default:
+		_, err := r.closeIssue(ctx, githubIssueInstance)
+		return err
+	}
 }
 
-func (r *GithubIssueReconciler) closeIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) error {
+// closeIssue posts the deletion comment and closes the remote issue, it returns nil when the issue was never opened.
+func (r *GithubIssueReconciler) closeIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (*tracker.Issue, error) {
 	logger := log.FromContext(ctx)
 
 	logger.Info("Trying to close issue")
@@ -127,132 +294,232 @@ func (r *GithubIssueReconciler) closeIssue(ctx context.Context, githubIssueInsta
 
 	if err != nil {
 		logger.Error(err, "Could not get remote issue on repo")
-		return err
+		return nil, err
+	}
+
+	if issueOnRepo == nil {
+		logger.Info("Remote issue was never opened, nothing to close")
+		return nil, nil
+	}
+
+	if issueOnRepo.State == "closed" {
+		logger.Info("Remote issue is already closed")
+		return issueOnRepo, nil
+	}
+
+	if githubIssueInstance.Spec.DeletionComment != "" {
+		err = r.Tracker.CreateComment(ctx, r.repository(githubIssueInstance), issueOnRepo.Number, githubIssueInstance.Spec.DeletionComment)
+
+		if err != nil {
+			logger.Error(err, "Could not post the deletion comment")
+			return nil, err
+		}
 	}
 
-	err = r.updateIssue(ctx, githubIssueInstance, issueOnRepo, utils.UpdatedValue{Key: "state", Value: "closed"})
+	request := tracker.IssueRequest{State: tracker.StringPtr("closed")}
+	if githubIssueInstance.Spec.DeletionStateReason != "" {
+		request.StateReason = tracker.StringPtr(string(githubIssueInstance.Spec.DeletionStateReason))
+	}
+
+	closedIssue, err := r.updateIssue(ctx, githubIssueInstance, issueOnRepo, request)
 
 	if err != nil {
 		logger.Error(err, "Could not change status of issue to closed")
-		return err
+		return nil, err
 	}
 
-	return nil
+	return closedIssue, nil
 }
 
-func (r *GithubIssueReconciler) updateIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, remoteIssue utils.GithubReponseWantedProperties, updatedValue utils.UpdatedValue) error {
+func (r *GithubIssueReconciler) lockIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, issueOnRepo *tracker.Issue) error {
 	logger := log.FromContext(ctx)
-	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
 
-	res, err := restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", os.Getenv(loadedConfig.EnvName))).
-		SetBody(map[string]interface{}{
-			"owner":          owner,
-			"repo":           repo,
-			"issue_number":   strconv.Itoa(remoteIssue.Number),
-			"title":          remoteIssue.Title,
-			updatedValue.Key: updatedValue.Value,
-		}).
-		Post(fmt.Sprintf("https://%s/repos/%s/%s/issues/%s", loadedConfig.GithubApi.BaseUrl, owner, repo, strconv.Itoa(remoteIssue.Number)))
-
-	if res.StatusCode() == 401 || res.StatusCode() == 404 {
-		logger.Error(err, "Bad credentials, please update the access token in your secret")
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Please update your access token inside the secret we created for your object and ensure your repo is correct")
-		return errors.New("bad credentials")
-	} else {
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "True", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Your object repo is correct with corresponding access token")
+	if issueOnRepo.Locked {
+		logger.Info("Remote issue is already locked")
+		return nil
 	}
 
+	err := r.Tracker.LockIssue(ctx, r.repository(githubIssueInstance), issueOnRepo.Number)
+
 	if err != nil {
-		logger.Error(err, "Failed to update remote issue")
+		logger.Error(err, "Could not lock the remote issue conversation")
 		return err
 	}
 
 	return nil
 }
 
+func (r *GithubIssueReconciler) updateIssue(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, remoteIssue *tracker.Issue, request tracker.IssueRequest) (*tracker.Issue, error) {
+	logger := log.FromContext(ctx)
+
+	updatedIssue, err := r.Tracker.UpdateIssue(ctx, r.repository(githubIssueInstance), remoteIssue.Number, request)
+	r.setConditionAccessToken(ctx, githubIssueInstance, err)
+
+	if err != nil {
+		logger.Error(err, "Failed to update remote issue")
+		r.recordEvent(githubIssueInstance, corev1.EventTypeWarning, "IssueUpdateFailed", fmt.Sprintf("Could not update issue #%d: %s", remoteIssue.Number, err.Error()))
+		return nil, err
+	}
+
+	repoLabel := metrics.RepoLabel(r.repository(githubIssueInstance))
+	metrics.IssuesUpdated.WithLabelValues(repoLabel).Inc()
+	if remoteIssue.State != "closed" && updatedIssue.State == "closed" {
+		metrics.IssuesClosed.WithLabelValues(repoLabel).Inc()
+		r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "IssueClosed", fmt.Sprintf("Closed issue #%d", updatedIssue.Number))
+	} else if remoteIssue.State == "closed" && updatedIssue.State != "closed" {
+		r.recordEvent(githubIssueInstance, corev1.EventTypeNormal, "IssueReopened", fmt.Sprintf("Reopened issue #%d", updatedIssue.Number))
+	}
+
+	return updatedIssue, nil
+}
+
 func (r *GithubIssueReconciler) extractRepoAndOwner(githubIssueInstance *assignmentcoreiov1.GithubIssue) (owner string, repoName string) {
-	givenUrl := githubIssueInstance.Spec.Repo
+	givenUrl := strings.TrimPrefix(githubIssueInstance.Spec.Repo, "https://")
 	urlSplitted := strings.Split(givenUrl, "/")
 	lengthOfParts := len(urlSplitted)
-	urlSplitted = urlSplitted[lengthOfParts-2:]
+	if lengthOfParts < 3 {
+		return "", urlSplitted[lengthOfParts-1]
+	}
 
-	// based of the github url standart of https://github.com/{owner}/{repo}
-	owner = urlSplitted[0]
-	repoName = urlSplitted[1]
+	// based of the github url standart of https://github.com/{owner}/{repo},
+	// gitlab projects may sit in nested groups (https://gitlab.com/{group}/{subgroup}/{project}) which all make up the owner
+	owner = strings.Join(urlSplitted[1:lengthOfParts-1], "/")
+	repoName = urlSplitted[lengthOfParts-1]
 	return
 }
 
-func (r *GithubIssueReconciler) isIssueExist(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) (bool, error) {
+// extractRepoHost returns the lowercase host of the repo url, github.com when it has none.
+func (r *GithubIssueReconciler) extractRepoHost(githubIssueInstance *assignmentcoreiov1.GithubIssue) string {
+	return utils.RepoHost(githubIssueInstance.Spec.Repo)
+}
+
+func (r *GithubIssueReconciler) repository(githubIssueInstance *assignmentcoreiov1.GithubIssue) tracker.Repository {
+	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
+
+	return tracker.Repository{Host: r.extractRepoHost(githubIssueInstance), Owner: owner, Name: repo}
+}
+
+func (r *GithubIssueReconciler) getAllRepoIssues(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) ([]tracker.Issue, error) {
 	logger := log.FromContext(ctx)
-	isExist := false
 
-	allRepoIssues, err := r.getAllRepoIssues(ctx, githubIssueInstance)
+	githubIssues, err := r.Tracker.ListIssues(ctx, r.repository(githubIssueInstance), tracker.ListOptions{State: "all"})
+	r.setConditionAccessToken(ctx, githubIssueInstance, err)
 
 	if err != nil {
-		return isExist, err
-	}
+		logger.Error(err, "Could not list all the issues of the wanted repository")
 
-	for _, currentRepoIssue := range allRepoIssues {
-		if currentRepoIssue.Title == githubIssueInstance.Spec.Title {
-			logger.Info(fmt.Sprintf("Issues with title -> %s already exist on this repo", githubIssueInstance.Spec.Title))
-			isExist = true
-			break
-		}
+		return nil, err
 	}
 
-	return isExist, nil
+	return githubIssues, nil
 }
 
-func (r *GithubIssueReconciler) getAllRepoIssues(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) ([]utils.GithubReponseWantedProperties, error) {
+// updateLinkedPullRequests records the pull requests linked to the remote issue in the status and returns them,
+// on failure the previously recorded pull requests are kept and nil is returned.
+func (r *GithubIssueReconciler) updateLinkedPullRequests(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, remoteIssue *tracker.Issue) []tracker.PullRequest {
 	logger := log.FromContext(ctx)
-	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
-	var githubIssues []utils.GithubReponseWantedProperties
 
-	res, err := restyClient.R().
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", os.Getenv(loadedConfig.EnvName))).
-		SetResult(&githubIssues).
-		Get(fmt.Sprintf("https://%s/repos/%s/%s/issues", loadedConfig.GithubApi.BaseUrl, owner, repo))
+	pullRequests, err := r.Tracker.ListLinkedPullRequests(ctx, r.repository(githubIssueInstance), remoteIssue.Number)
 
-	if res.StatusCode() == 401 || res.StatusCode() == 404 {
-		logger.Error(err, "Bad credentials, please update the access token in your secret")
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Please update your access token inside the secret we created for your object and ensure your repo is correct")
-		return nil, errors.New("bad credentials")
-	} else {
-		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "True", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Your object repo is correct with corresponding access token")
+	if err != nil {
+		logger.Error(err, "Could not get the pull requests linked to the remote issue")
+		return nil
 	}
 
-	if err != nil {
-		logger.Error(err, "Could not list all the issues of the wanted repository")
+	linkedPullRequests := make([]assignmentcoreiov1.LinkedPullRequest, 0, len(pullRequests))
+	for _, pullRequest := range pullRequests {
+		linkedPullRequests = append(linkedPullRequests, assignmentcoreiov1.LinkedPullRequest{
+			Repo:   pullRequest.Repository.String(),
+			Number: pullRequest.Number,
+			URL:    pullRequest.HTMLURL,
+			State:  pullRequest.State,
+			Merged: pullRequest.Merged,
+		})
+	}
 
-		return nil, err
+	githubIssueInstance.Status.PullRequests = linkedPullRequests
+	return pullRequests
+}
+
+// updatePullRequestConditions raises HasOpenPR and PRMerged from the pull requests recorded in the status.
+func (r *GithubIssueReconciler) updatePullRequestConditions(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) {
+	var open []string
+	var merged []string
+
+	for _, pullRequest := range githubIssueInstance.Status.PullRequests {
+		name := fmt.Sprintf("%s#%d", pullRequest.Repo, pullRequest.Number)
+
+		if pullRequest.Merged {
+			merged = append(merged, name)
+		} else if pullRequest.State == "open" {
+			open = append(open, name)
+		}
 	}
 
-	return githubIssues, nil
+	if len(open) != 0 {
+		r.setConditionHasOpenPR(ctx, githubIssueInstance, "True", fmt.Sprintf("Open pull requests: %s", strings.Join(open, ", ")))
+	} else {
+		r.setConditionHasOpenPR(ctx, githubIssueInstance, "False", "Issue has no open pull request")
+	}
+
+	if len(merged) != 0 {
+		r.setConditionPRMerged(ctx, githubIssueInstance, "True", fmt.Sprintf("Merged pull requests: %s", strings.Join(merged, ", ")))
+	} else {
+		r.setConditionPRMerged(ctx, githubIssueInstance, "False", "Issue has no merged pull request")
+	}
 }
 
-func (r *GithubIssueReconciler) updateIssueHavePRCondition(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue) {
+// updateIssueStatus records the remote issue in the object status, the lifecycle metrics are observed
+// only once the status holding their timestamps is written so a failed write never counts them twice.
+func (r *GithubIssueReconciler) updateIssueStatus(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, remoteIssue *tracker.Issue, pullRequests []tracker.PullRequest) error {
 	logger := log.FromContext(ctx)
-	owner, repo := r.extractRepoAndOwner(githubIssueInstance)
-	// var RemotePR utils.GithubPrResponseWantedProperties
 
-	remoteIssue, err := r.findRelevantIssue(ctx, githubIssueInstance)
+	githubIssueInstance.Status.IssueNumber = remoteIssue.Number
+	githubIssueInstance.Status.IssueKey = remoteIssue.Key
+	githubIssueInstance.Status.HTMLURL = remoteIssue.HTMLURL
+	githubIssueInstance.Status.State = remoteIssue.State
+	githubIssueInstance.Status.RemoteTitle = remoteIssue.Title
+	githubIssueInstance.Status.RemoteBody = remoteIssue.Body
+	githubIssueInstance.Status.RemoteUpdatedAt = &metav1.Time{Time: remoteIssue.UpdatedAt}
+	githubIssueInstance.Status.LastSyncedTime = &metav1.Time{Time: time.Now()}
+	observations := r.recordIssueLifecycle(githubIssueInstance, remoteIssue)
+	observations = append(observations, r.recordFirstPullRequest(githubIssueInstance, pullRequests)...)
+
+	err := r.updateStatus(ctx, githubIssueInstance)
 	if err != nil {
-		logger.Error(err, "Could not get remote issue when trying to determine if pr exist")
+		logger.Error(err, "Could not record remote issue in status")
+		return err
+	}
 
-	} else {
-		res, err := restyClient.R().
-			SetHeader("Authorization", fmt.Sprintf("Bearer %s", os.Getenv(loadedConfig.EnvName))).
-			Get(fmt.Sprintf("https://%s/repos/%s/%s/issues/%s/events", loadedConfig.GithubApi.BaseUrl, owner, repo, strconv.Itoa(remoteIssue.Number)))
+	r.observeLifecycle(githubIssueInstance, observations)
+	return nil
+}
 
-		if err != nil {
-			logger.Error(err, "Could not get remote issue events when trying to determine if pr exist")
+// asRateLimitError unwraps a tracker rate limit error.
+func asRateLimitError(err error) (*tracker.RateLimitError, bool) {
+	var rateLimitErr *tracker.RateLimitError
+
+	if errors.As(err, &rateLimitErr) {
+		return rateLimitErr, true
+	}
+
+	return nil, false
+}
+
+// setConditionAccessToken reflects the outcome of a tracker call in the access token condition,
+// a 401 or a 404 means the user still has to fix the token in his secret or the repo url.
+func (r *GithubIssueReconciler) setConditionAccessToken(ctx context.Context, githubIssueInstance *assignmentcoreiov1.GithubIssue, err error) {
+	logger := log.FromContext(ctx)
+
+	if errors.Is(err, tracker.ErrBadCredentials) || errors.Is(err, tracker.ErrNotFound) {
+		logger.Error(err, "Bad credentials, please update the access token in your secret")
+		if errors.Is(err, tracker.ErrBadCredentials) {
+			r.recordEvent(githubIssueInstance, corev1.EventTypeWarning, "AuthenticationFailed", "The remote rejected the access token, please update it in your credentials secret")
 		} else {
-			if res.String() != "[]" {
-				r.setConditionIssueHasPullRequest(ctx, githubIssueInstance, "True")
-			} else {
-				r.setConditionIssueHasPullRequest(ctx, githubIssueInstance, "False")
-			}
+			r.recordEvent(githubIssueInstance, corev1.EventTypeWarning, "RepoNotFound", fmt.Sprintf("%s was not found, check the repo url and that the access token can read it", r.repository(githubIssueInstance)))
 		}
+		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Please update your access token inside the secret we created for your object and ensure your repo is correct")
+	} else {
+		r.setCondition(ctx, githubIssueInstance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "True", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Your object repo is correct with corresponding access token")
 	}
 }
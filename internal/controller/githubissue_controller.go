@@ -3,30 +3,73 @@ package controller
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracing"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/github"
+)
+
+// Auth types of a credentials secret.
+const (
+	AuthTypeToken = "token"
+	AuthTypeApp   = "app"
 )
 
 // GithubIssueReconciler reconciles a GithubIssue object
 type GithubIssueReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Tracker is the remote issue tracker every issue operation goes through.
+	Tracker tracker.IssueTracker
+	// GithubApps exchanges github App credentials for installation tokens of each host,
+	// secrets with App credentials are rejected for hosts missing from it.
+	GithubApps map[string]*github.AppTokenSource
+	// WebhookEvents receives the objects affected by github webhook deliveries, nil when the receiver is disabled.
+	WebhookEvents <-chan event.GenericEvent
+	// Recorder emits the events describing every remote action on the object, events are dropped when it is nil.
+	Recorder record.EventRecorder
+	// MaxConcurrentReconciles is how many objects are reconciled in parallel, defaults to 1.
+	// Every reconcile carries its own token in its context so objects using different credentials never mix them.
+	MaxConcurrentReconciles int
 }
 
 //+kubebuilder:rbac:groups=assignment.core.io.assignment.core.io,resources=githubissues,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=assignment.core.io.assignment.core.io,resources=githubcredentialbindings,verbs=get;list;watch
 //+kubebuilder:rbac:groups=assignment.core.io.assignment.core.io,resources=githubissues/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=assignment.core.io.assignment.core.io,resources=githubissues/finalizers,verbs=update
 
-func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
+	ctx, span := tracing.Start(ctx, "Reconcile",
+		attribute.String("k8s.namespace.name", req.Namespace),
+		attribute.String("githubissue.name", req.Name))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	logger := log.FromContext(ctx)
 
 	logger.Info("Enter reconcile function")
@@ -51,13 +94,24 @@ func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	result, err = r.reconcileGithubIssue(ctx, req, instance, loadedConfig)
+	result, err = r.handleRateLimit(ctx, instance, result, err)
+
+	r.updateReadyCondition(ctx, instance, err)
+	return result, err
+}
+
+// reconcileGithubIssue drives the remote issue of an existing object towards its spec.
+func (r *GithubIssueReconciler) reconcileGithubIssue(ctx context.Context, req ctrl.Request, instance *assignmentcoreiov1.GithubIssue, loadedConfig *config.Config) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
 	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
 		// This item has been marked for deletion
 		if r.isFinalizerExist(instance) {
-			err := r.closeIssue(ctx, instance)
+			result, err := r.applyDeletionPolicy(ctx, instance, loadedConfig)
 
-			if err != nil {
-				logger.Error(err, "Could not preform finalizer actions, this object will be deleted anyway")
+			if result != nil {
+				return *result, err
 			}
 
 			err = r.removeFinalizer(instance, ctx)
@@ -71,30 +125,53 @@ func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	var result *ctrl.Result
+	var err error
 
 	// Ensure dependencies
-	result, err = r.ensureSecret(instance, ctx, req, loadedConfig.AuthSecret.GithubSecretName, loadedConfig.AuthSecret.GithubSecretKeyName)
-	if result != nil {
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+	if instance.Spec.CredentialsRef == nil && instance.Spec.CreateCredentialsSecret {
+		result, err = r.ensureSecret(instance, ctx, req, loadedConfig.AuthSecret.GithubSecretName, loadedConfig.AuthSecret.GithubSecretKeyName)
+		if result != nil {
+			return ctrl.Result{RequeueAfter: 5 * time.Second}, err
+		}
+	}
+
+	credentials, err := r.resolveCredentials(ctx, instance, loadedConfig)
+	if err != nil {
+		r.setConditionCredentialsResolved(ctx, instance, "False", fmt.Sprintf("Could not look up the credential bindings: %s", err.Error()))
+		return ctrl.Result{}, err
 	}
 
-	result, err = r.getValueFromSecretAndStoreEnv(ctx, req, fmt.Sprintf("%s-%s", req.Name, loadedConfig.AuthSecret.GithubSecretName), loadedConfig.AuthSecret.GithubSecretKeyName, loadedConfig.EnvName)
+	token, result, err := r.getAccessTokenFromSecret(ctx, credentials.secret, credentials.key, r.repository(instance).Host)
 	if result != nil {
+		r.setConditionCredentialsResolved(ctx, instance, "False", fmt.Sprintf("Could not read the credentials from %s", credentials))
+		r.recordEvent(instance, corev1.EventTypeWarning, "CredentialsUnavailable", fmt.Sprintf("Could not read the credentials from %s: %v", credentials, err))
+		r.setCondition(ctx, instance, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False", "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "Please create the credentials secret referenced by your object")
 		return ctrl.Result{}, err
 	}
 
+	r.setConditionCredentialsResolved(ctx, instance, "True", fmt.Sprintf("Using the credentials from %s", credentials))
+	ctx = tracker.WithToken(ctx, token)
+
 	r.addHelperLabelsIfNeeded(instance, ctx)
 	r.addFinalizersIfNeeded(instance, ctx)
 
-	existInRepo, err := r.isIssueExist(ctx, instance)
+	remoteIssue, err := r.findRelevantIssue(ctx, instance)
 
 	if err != nil {
 		logger.Error(err, "Could not verify if the issue is existing on repo")
 		return ctrl.Result{}, err
 	}
 
-	if !existInRepo {
-		err := r.openIssue(ctx, instance)
+	syncPolicy := r.syncPolicy(instance)
+
+	if remoteIssue == nil {
+		if syncPolicy == assignmentcoreiov1.SyncPolicyObserve {
+			logger.Info("Remote issue does not exist and sync policy Observe never opens it")
+			r.setConditionRemoteDrift(ctx, instance, "True", "Remote issue does not exist, sync policy Observe never opens it")
+			return ctrl.Result{}, nil
+		}
+
+		remoteIssue, err = r.openIssue(ctx, instance)
 
 		if err != nil {
 			r.setConditionIssueIsOpen(ctx, instance, "False")
@@ -102,8 +179,15 @@ func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 
 		r.setConditionIssueIsOpen(ctx, instance, "True")
-	} else {
-		isUpdated, err := r.updateIssueOnRepoIfNeeded(ctx, instance)
+	}
+
+	driftBefore := r.remoteDrift(instance, remoteIssue)
+
+	if syncPolicy == assignmentcoreiov1.SyncPolicyEnforce {
+		// Also runs right after opening, so an issue created with spec.state closed is closed in the same cycle
+		// and an adopted closed issue is reopened instead of duplicated.
+		var isUpdated bool
+		remoteIssue, isUpdated, err = r.updateIssueOnRepoIfNeeded(ctx, instance, remoteIssue)
 
 		if err != nil {
 			return ctrl.Result{}, err
@@ -112,15 +196,140 @@ func (r *GithubIssueReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		if isUpdated {
 			r.updateConditionToAllOldRelevantObjects(ctx, instance)
 		}
+
+		remoteIssue, err = r.updateIssueMetadataIfNeeded(ctx, instance, remoteIssue)
+
+		if err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	r.updateIssueHavePRCondition(ctx, instance)
+	r.updateRemoteDriftCondition(ctx, instance, remoteIssue, driftBefore)
+	pullRequests := r.updateLinkedPullRequests(ctx, instance, remoteIssue)
+
+	err = r.updateIssueStatus(ctx, instance, remoteIssue, pullRequests)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	r.updatePullRequestConditions(ctx, instance)
 	return ctrl.Result{}, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// handleRateLimit turns a rate limit error into a requeue once the quota resets instead of an error backoff,
+// and keeps the RateLimited condition up to date.
+func (r *GithubIssueReconciler) handleRateLimit(ctx context.Context, instance *assignmentcoreiov1.GithubIssue, result ctrl.Result, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	rateLimitErr, isRateLimited := asRateLimitError(err)
+
+	if isRateLimited {
+		retryAfter := rateLimitErr.RetryAfter(time.Now())
+		logger.Info(fmt.Sprintf("Rate limited by github, requeue in %s", retryAfter))
+		r.setConditionRateLimited(ctx, instance, "True", fmt.Sprintf("Github rate limit exceeded, retrying at %s", rateLimitErr.ResetAt.UTC().Format(time.RFC3339)))
+		r.recordEvent(instance, corev1.EventTypeWarning, "RateLimited", fmt.Sprintf("Rate limit exceeded, retrying at %s", rateLimitErr.ResetAt.UTC().Format(time.RFC3339)))
+
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+
+	if err == nil && instance.ObjectMeta.DeletionTimestamp.IsZero() && meta.IsStatusConditionTrue(instance.Status.Conditons, "RateLimited") {
+		r.setConditionRateLimited(ctx, instance, "False", "Github rate limit is not exceeded")
+	}
+
+	return result, err
+}
+
+// updateRemoteDriftCondition reports the fields where the remote issue differs from the spec,
+// under Enforce it reports the remote edits that were just overwritten instead.
+func (r *GithubIssueReconciler) updateRemoteDriftCondition(ctx context.Context, instance *assignmentcoreiov1.GithubIssue, remoteIssue *tracker.Issue, driftBefore []string) {
+	driftAfter := r.remoteDrift(instance, remoteIssue)
+
+	if len(driftAfter) != 0 {
+		r.setConditionRemoteDrift(ctx, instance, "True", fmt.Sprintf("Remote issue differs from spec (sync policy %s): %s", r.syncPolicy(instance), strings.Join(driftAfter, ", ")))
+	} else if len(driftBefore) != 0 {
+		r.setConditionRemoteDrift(ctx, instance, "False", fmt.Sprintf("Overwrote remote changes: %s", strings.Join(driftBefore, ", ")))
+	} else {
+		r.setConditionRemoteDrift(ctx, instance, "False", "Remote issue matches the spec")
+	}
+}
+
+// applyDeletionPolicy runs the finalizer actions, a non nil result means the finalizer has to stay for another attempt.
+// Failures are retried up to the configured budget, after that the object is released and the remote issue is left as is.
+func (r *GithubIssueReconciler) applyDeletionPolicy(ctx context.Context, instance *assignmentcoreiov1.GithubIssue, loadedConfig *config.Config) (*ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if instance.Spec.DeletionPolicy == assignmentcoreiov1.DeletionPolicyOrphan {
+		return nil, r.finalizeIssue(ctx, instance)
+	}
+
+	credentials, err := r.resolveCredentials(ctx, instance, loadedConfig)
+
+	var token string
+	if err == nil {
+		token, _, err = r.getAccessTokenFromSecret(ctx, credentials.secret, credentials.key, r.repository(instance).Host)
+	}
+
+	if err == nil {
+		err = r.finalizeIssue(tracker.WithToken(ctx, token), instance)
+	}
+
+	if err == nil {
+		return nil, nil
+	}
+
+	// Waiting for the rate limit to reset is not a failed attempt
+	if _, isRateLimited := asRateLimitError(err); isRateLimited {
+		return &ctrl.Result{}, err
+	}
+
+	instance.Status.DeletionAttempts++
+	if instance.Status.DeletionAttempts >= loadedConfig.DeletionRetryLimit {
+		logger.Error(err, fmt.Sprintf("Could not apply deletion policy %s after %d attempts, the remote issue is left as is and this object will be deleted anyway", instance.Spec.DeletionPolicy, instance.Status.DeletionAttempts))
+		return nil, nil
+	}
+
+	logger.Error(err, fmt.Sprintf("Could not apply deletion policy %s (attempt %d of %d), will retry", instance.Spec.DeletionPolicy, instance.Status.DeletionAttempts, loadedConfig.DeletionRetryLimit))
+	r.setCondition(ctx, instance, "RemoteIssueFinalized", "False", "RemoteIssueFinalized", fmt.Sprintf("Could not apply deletion policy: %s", err.Error()))
+	r.recordEvent(instance, corev1.EventTypeWarning, "DeletionPolicyFailed", fmt.Sprintf("Could not apply deletion policy %s (attempt %d of %d): %s", instance.Spec.DeletionPolicy, instance.Status.DeletionAttempts, loadedConfig.DeletionRetryLimit, err.Error()))
+
+	if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+		logger.Error(statusErr, "Could not record deletion attempt")
+	}
+
+	return &ctrl.Result{}, err
+}
+
+// SetupWithManager sets up the controller with the Manager and registers the GithubIssue gauges on the metrics registry.
 func (r *GithubIssueReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	err := metrics.Registry.Register(&issueCollector{reconciler: r})
+
+	if err != nil {
+		return err
+	}
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&assignmentcoreiov1.GithubIssue{}).
-		Complete(r)
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		WithEventFilter(predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return !isStatusOnlyUpdate(e.ObjectOld, e.ObjectNew)
+			},
+		})
+
+	if r.WebhookEvents != nil {
+		controllerBuilder = controllerBuilder.WatchesRawSource(&source.Channel{Source: r.WebhookEvents}, &handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
+}
+
+// isStatusOnlyUpdate reports whether an update event was caused by our own status writes.
+// Periodic resyncs keep the same resource version and still pass, so the remote issue is polled every sync period.
+func isStatusOnlyUpdate(oldObject client.Object, newObject client.Object) bool {
+	return oldObject.GetResourceVersion() != newObject.GetResourceVersion() &&
+		oldObject.GetGeneration() == newObject.GetGeneration() &&
+		reflect.DeepEqual(oldObject.GetLabels(), newObject.GetLabels()) &&
+		reflect.DeepEqual(oldObject.GetAnnotations(), newObject.GetAnnotations()) &&
+		reflect.DeepEqual(oldObject.GetFinalizers(), newObject.GetFinalizers()) &&
+		oldObject.GetDeletionTimestamp().Equal(newObject.GetDeletionTimestamp())
 }
@@ -19,24 +19,36 @@ package controller
 import (
 	"context"
 	"fmt"
-	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 	"github.com/idoSharon1/githubIssue-operator/cmd/config"
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 var _ = Describe("GithubIssue Controller", func() {
 	Context("When reconciling a resource", func() {
 		const resourceName = "test-resource"
-		ctx := context.Background()
+		// The specs reach the fake tracker directly, so they carry the token the reconciler would load from the secret.
+		ctx := tracker.WithToken(context.Background(), testAccessToken)
 
 		typeNamespacedName := types.NamespacedName{
 			Name:      resourceName,
@@ -59,7 +71,7 @@ var _ = Describe("GithubIssue Controller", func() {
 						Name:      fmt.Sprintf("%s-%s", typeNamespacedName.Name, loadedConfig.AuthSecret.GithubSecretName),
 						Namespace: typeNamespacedName.Namespace,
 					},
-					StringData: map[string]string{loadedConfig.AuthSecret.GithubSecretKeyName: os.Getenv("TESTING_ACCESS_TOKEN")},
+					StringData: map[string]string{loadedConfig.AuthSecret.GithubSecretKeyName: testAccessToken},
 				}
 				Expect(k8sClient.Create(ctx, correspondsSecret)).To(Succeed())
 				createdSecret := &corev1.Secret{}
@@ -94,8 +106,9 @@ var _ = Describe("GithubIssue Controller", func() {
 
 		AfterEach(func() {
 			controllerReconciler := &GithubIssueReconciler{
-				Client: k8sClient,
-				Scheme: k8sClient.Scheme(),
+				Client:  k8sClient,
+				Scheme:  k8sClient.Scheme(),
+				Tracker: fakeTracker,
 			}
 			affectedResource := &assignmentcoreiov1.GithubIssue{}
 			err := k8sClient.Get(ctx, typeNamespacedName, affectedResource)
@@ -115,8 +128,9 @@ var _ = Describe("GithubIssue Controller", func() {
 		It("should delete remote issue on delete", func() {
 			By("implementing the finalizer logic", func() {
 				controllerReconciler := &GithubIssueReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
 				}
 
 				resource := &assignmentcoreiov1.GithubIssue{}
@@ -128,18 +142,86 @@ var _ = Describe("GithubIssue Controller", func() {
 				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: resource.Namespace, Name: resource.Name}})
 				Expect(err).NotTo(HaveOccurred())
 				Eventually(func() bool {
-					isSucceed, _ := controllerReconciler.isIssueExist(ctx, resource)
+					for _, remoteIssue := range fakeTracker.Issues(controllerReconciler.repository(resource)) {
+						if remoteIssue.Title == resource.Spec.Title && remoteIssue.State == "open" {
+							return true
+						}
+					}
 
-					return isSucceed
+					return false
 				}).Should(BeFalse())
 			})
 		})
 
+		It("Should apply the deletion policy on delete", func() {
+			By("leaving the remote issue open with the Orphan policy", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "orphaned issue"
+				resource.Spec.DeletionPolicy = assignmentcoreiov1.DeletionPolicyOrphan
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				issueNumber := resource.Status.IssueNumber
+
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), issueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.State).To(Equal("open"))
+			})
+		})
+
+		It("Should lock and comment with the Lock policy", func() {
+			By("closing the remote issue as not planned", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "locked issue"
+				resource.Spec.DeletionPolicy = assignmentcoreiov1.DeletionPolicyLock
+				resource.Spec.DeletionStateReason = assignmentcoreiov1.IssueStateReasonNotPlanned
+				resource.Spec.DeletionComment = "Tracked elsewhere"
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				issueNumber := resource.Status.IssueNumber
+
+				Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), issueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.State).To(Equal("closed"))
+				Expect(remoteIssue.StateReason).To(Equal("not_planned"))
+				Expect(remoteIssue.Locked).To(BeTrue())
+				Expect(fakeTracker.Comments(controllerReconciler.repository(resource), issueNumber)).To(Equal([]string{"Tracked elsewhere"}))
+			})
+		})
+
 		It("Should create remote issue if not exist", func() {
 			By("running regular reconcile of new githubIssue", func() {
 				controllerReconciler := &GithubIssueReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
 				}
 
 				resource := &assignmentcoreiov1.GithubIssue{}
@@ -163,20 +245,531 @@ var _ = Describe("GithubIssue Controller", func() {
 					Expect(err).NotTo(HaveOccurred())
 					return true
 				}).Should(BeTrue())
-				Eventually(func() bool {
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.IssueNumber).NotTo(BeZero())
 
-					isSucceed, _ := controllerReconciler.isIssueExist(ctx, resource)
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), resource.Status.IssueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.Title).To(Equal(newTitle))
+			})
+		})
 
-					return isSucceed
-				}).Should(BeTrue())
+		It("Should record the remote issue in status", func() {
+			By("storing the issue number after opening it", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				updatedResource := &assignmentcoreiov1.GithubIssue{}
+				err = k8sClient.Get(ctx, typeNamespacedName, updatedResource)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(updatedResource.Status.IssueNumber).NotTo(BeZero())
+				Expect(updatedResource.Status.LastSyncedTime).NotTo(BeNil())
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(updatedResource), updatedResource.Status.IssueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.HTMLURL).To(Equal(updatedResource.Status.HTMLURL))
+				Expect(remoteIssue.State).To(Equal(updatedResource.Status.State))
+			})
+		})
+
+		It("Should summarize the conditions in Ready and the phase", func() {
+			By("marking the object Ready once the remote issue is in sync", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "Ready")).To(BeTrue())
+				Expect(resource.Status.Phase).To(Equal(assignmentcoreiov1.IssuePhaseOpen))
+				Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
+				for _, condition := range resource.Status.Conditons {
+					Expect(condition.ObservedGeneration).To(Equal(resource.Generation))
+				}
+
+				resource.Spec.State = assignmentcoreiov1.IssueStateClosed
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.Phase).To(Equal(assignmentcoreiov1.IssuePhaseClosed))
+				Expect(resource.Status.ObservedGeneration).To(Equal(resource.Generation))
+				Expect(meta.FindStatusCondition(resource.Status.Conditons, "Ready").ObservedGeneration).To(Equal(resource.Generation))
+			})
+		})
+
+		It("Should not report Ready while the remote rejects the metadata", func() {
+			By("blocking Ready on a False IssueMetadataSynced condition", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "Ready")).To(BeTrue())
+
+				controllerReconciler.setConditionIssueMetadataSynced(ctx, resource, "False", "Remote did not accept: assignees")
+				controllerReconciler.updateReadyCondition(ctx, resource, nil)
+
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionFalse(resource.Status.Conditons, "Ready")).To(BeTrue())
+				Expect(resource.Status.Phase).To(Equal(assignmentcoreiov1.IssuePhaseFailed))
+			})
+		})
+
+		It("Should count the issues it opens and closes", func() {
+			By("incrementing the issue counters of the repo", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				repoLabel := "github.com/idoSharon1/NamespaceLabel-operator"
+				createdBefore := testutil.ToFloat64(metrics.IssuesCreated.WithLabelValues(repoLabel))
+				closedBefore := testutil.ToFloat64(metrics.IssuesClosed.WithLabelValues(repoLabel))
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(testutil.ToFloat64(metrics.IssuesCreated.WithLabelValues(repoLabel))).To(Equal(createdBefore + 1))
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.State = assignmentcoreiov1.IssueStateClosed
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(testutil.ToFloat64(metrics.IssuesClosed.WithLabelValues(repoLabel))).To(Equal(closedBefore + 1))
+			})
+		})
+
+		It("Should observe the issue lifecycle once", func() {
+			By("recording the remote lifecycle timestamps in status", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				histograms := []*prometheus.HistogramVec{metrics.IssueTimeToOpen, metrics.IssueTimeToFirstPullRequest, metrics.IssueTimeToClose}
+				samplesBefore := make([]uint64, len(histograms))
+				for i, histogram := range histograms {
+					samplesBefore[i] = histogramSamples(histogram)
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				repo := controllerReconciler.repository(resource)
+				fakeTracker.LinkPullRequest(repo, resource.Status.IssueNumber, tracker.PullRequest{Repository: repo, Number: 100, State: "open"})
+
+				resource.Spec.State = assignmentcoreiov1.IssueStateClosed
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				for i := 0; i < 2; i++ {
+					_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.OpenedAt).NotTo(BeNil())
+				Expect(resource.Status.FirstPullRequestLinkedAt).NotTo(BeNil())
+				Expect(resource.Status.ClosedAt).NotTo(BeNil())
+				for i, histogram := range histograms {
+					Expect(histogramSamples(histogram)).To(Equal(samplesBefore[i] + 1))
+				}
+			})
+		})
+
+		It("Should not observe the lifecycle before the status is written", func() {
+			By("failing the status writes of the first reconcile", func() {
+				samplesBefore := histogramSamples(metrics.IssueTimeToOpen)
+
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  &failingStatusClient{Client: k8sClient},
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).To(HaveOccurred())
+				Expect(histogramSamples(metrics.IssueTimeToOpen)).To(Equal(samplesBefore))
+
+				controllerReconciler.Client = k8sClient
+				for i := 0; i < 2; i++ {
+					_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+					Expect(err).NotTo(HaveOccurred())
+				}
+				Expect(histogramSamples(metrics.IssueTimeToOpen)).To(Equal(samplesBefore + 1))
+			})
+		})
+
+		It("Should record an event for every remote action", func() {
+			By("emitting events when the issue is opened and closed", func() {
+				recorder := record.NewFakeRecorder(10)
+				controllerReconciler := &GithubIssueReconciler{
+					Client:   k8sClient,
+					Scheme:   k8sClient.Scheme(),
+					Tracker:  fakeTracker,
+					Recorder: recorder,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("Normal IssueOpened Opened issue #%d: %s", resource.Status.IssueNumber, resource.Status.HTMLURL))))
+
+				resource.Spec.State = assignmentcoreiov1.IssueStateClosed
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(recorder.Events).To(Receive(Equal(fmt.Sprintf("Normal IssueClosed Closed issue #%d", resource.Status.IssueNumber))))
+				Expect(recorder.Events).To(Receive(HavePrefix("Normal IssueUpdated")))
+			})
+		})
+
+		It("Should trace the reconcile and its status writes", func() {
+			By("recording the status writes as children of the Reconcile span", func() {
+				spans := tracetest.NewSpanRecorder()
+				previousProvider := otel.GetTracerProvider()
+				otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+				DeferCleanup(otel.SetTracerProvider, previousProvider)
+
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				ended := spans.Ended()
+				var reconcileSpan sdktrace.ReadOnlySpan
+				for _, span := range ended {
+					if span.Name() == "Reconcile" {
+						reconcileSpan = span
+					}
+				}
+				Expect(reconcileSpan).NotTo(BeNil())
+				Expect(reconcileSpan.Attributes()).To(ContainElement(attribute.String("githubissue.name", typeNamespacedName.Name)))
+
+				statusWrites := 0
+				for _, span := range ended {
+					if span.Name() == "UpdateStatus" {
+						statusWrites++
+						Expect(span.Parent().SpanID()).To(Equal(reconcileSpan.SpanContext().SpanID()))
+					}
+				}
+				Expect(statusWrites).To(BeNumerically(">", 0))
+			})
+		})
+
+		It("Should keep remote labels in sync with the spec", func() {
+			By("removing labels added on the remote", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				err := k8sClient.Get(ctx, typeNamespacedName, resource)
+				Expect(err).NotTo(HaveOccurred())
+				resource.Spec.Labels = []string{"bug"}
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+
+				_, err = fakeTracker.UpdateIssue(ctx, controllerReconciler.repository(resource), resource.Status.IssueNumber, tracker.IssueRequest{Labels: []string{"bug", "wontfix"}})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), resource.Status.IssueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.Labels).To(Equal([]string{"bug"}))
+			})
+		})
+
+		It("Should rename the remote issue when the title changes", func() {
+			By("patching the title of the issue stored in status", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				issueNumber := resource.Status.IssueNumber
+				issuesBefore := len(fakeTracker.Issues(controllerReconciler.repository(resource)))
+
+				resource.Spec.Title = "renamed title"
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.IssueNumber).To(Equal(issueNumber))
+				Expect(resource.GetLabels()[loadedConfig.TitleLabelKey]).To(Equal("renamed-title"))
+				Expect(fakeTracker.Issues(controllerReconciler.repository(resource))).To(HaveLen(issuesBefore))
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), issueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.Title).To(Equal("renamed title"))
+			})
+		})
+
+		It("Should close and reopen the remote issue from spec.state", func() {
+			By("flipping the state while keeping the same issue", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "stateful issue"
+				resource.Spec.State = assignmentcoreiov1.IssueStateClosed
+				resource.Spec.StateReason = assignmentcoreiov1.IssueStateReasonNotPlanned
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.State).To(Equal("closed"))
+				issueNumber := resource.Status.IssueNumber
+
+				resource.Spec.State = assignmentcoreiov1.IssueStateOpen
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.State).To(Equal("open"))
+				Expect(resource.Status.IssueNumber).To(Equal(issueNumber))
+			})
+		})
+
+		It("Should keep remote edits with the Adopt sync policy", func() {
+			By("recording the remote body and reporting drift", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "adopted issue"
+				resource.Spec.SyncPolicy = assignmentcoreiov1.SyncPolicyAdopt
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionFalse(resource.Status.Conditons, "RemoteDrift")).To(BeTrue())
+
+				_, err = fakeTracker.UpdateIssue(ctx, controllerReconciler.repository(resource), resource.Status.IssueNumber, tracker.IssueRequest{Body: tracker.StringPtr("edited on github")})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.RemoteBody).To(Equal("edited on github"))
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "RemoteDrift")).To(BeTrue())
+
+				remoteIssue, err := fakeTracker.GetIssue(ctx, controllerReconciler.repository(resource), resource.Status.IssueNumber)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(remoteIssue.Body).To(Equal("edited on github"))
+			})
+		})
+
+		It("Should record linked pull requests in status", func() {
+			By("raising HasOpenPR and PRMerged", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "issue with pull requests"
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.PullRequests).To(BeEmpty())
+				Expect(meta.IsStatusConditionFalse(resource.Status.Conditons, "HasOpenPR")).To(BeTrue())
+
+				repo := controllerReconciler.repository(resource)
+				fakeTracker.LinkPullRequest(repo, resource.Status.IssueNumber, tracker.PullRequest{Repository: repo, Number: 100, State: "open"})
+				fakeTracker.LinkPullRequest(repo, resource.Status.IssueNumber, tracker.PullRequest{Repository: repo, Number: 101, State: "closed", Merged: true})
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.PullRequests).To(HaveLen(2))
+				Expect(resource.Status.PullRequests[1].Merged).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "HasOpenPR")).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "PRMerged")).To(BeTrue())
+			})
+		})
+
+		It("Should read the credentials from a shared secret", func() {
+			By("following spec.credentialsRef", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				sharedSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "shared-github-credentials", Namespace: typeNamespacedName.Namespace},
+					StringData: map[string]string{"pat": testAccessToken},
+				}
+				Expect(k8sClient.Create(ctx, sharedSecret)).To(Succeed())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				resource.Spec.Title = "issue with shared credentials"
+				resource.Spec.CredentialsRef = &assignmentcoreiov1.SecretKeyRef{Name: sharedSecret.Name, Key: "pat"}
+				Expect(k8sClient.Update(ctx, resource)).To(Succeed())
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.IssueNumber).NotTo(BeZero())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "CredentialsResolved")).To(BeTrue())
+			})
+		})
+
+		It("Should route credentials through the most specific GithubCredentialBinding", func() {
+			By("naming the binding in the CredentialsResolved condition", func() {
+				controllerReconciler := &GithubIssueReconciler{
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
+				}
+
+				bindingSecret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "bound-github-credentials", Namespace: typeNamespacedName.Namespace},
+					StringData: map[string]string{"token": testAccessToken},
+				}
+				Expect(k8sClient.Create(ctx, bindingSecret)).To(Succeed())
+
+				ownerBinding := &assignmentcoreiov1.GithubCredentialBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "owner-binding"},
+					Spec: assignmentcoreiov1.GithubCredentialBindingSpec{
+						Repositories: []string{"idoSharon1/*"},
+						SecretRef:    assignmentcoreiov1.NamespacedSecretKeyRef{Namespace: bindingSecret.Namespace, Name: "missing-secret"},
+					},
+				}
+				repoBinding := &assignmentcoreiov1.GithubCredentialBinding{
+					ObjectMeta: metav1.ObjectMeta{Name: "repo-binding"},
+					Spec: assignmentcoreiov1.GithubCredentialBindingSpec{
+						Repositories: []string{"idoSharon1/NamespaceLabel-operator"},
+						SecretRef:    assignmentcoreiov1.NamespacedSecretKeyRef{Namespace: bindingSecret.Namespace, Name: bindingSecret.Name},
+					},
+				}
+				Expect(k8sClient.Create(ctx, ownerBinding)).To(Succeed())
+				Expect(k8sClient.Create(ctx, repoBinding)).To(Succeed())
+				defer func() {
+					Expect(k8sClient.Delete(ctx, ownerBinding)).To(Succeed())
+					Expect(k8sClient.Delete(ctx, repoBinding)).To(Succeed())
+				}()
+
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(resource.Status.Conditons).To(ContainElement(SatisfyAll(
+					HaveField("Type", "CredentialsResolved"),
+					HaveField("Status", metav1.ConditionTrue),
+					HaveField("Message", ContainSubstring("GithubCredentialBinding repo-binding")),
+				)))
+			})
+		})
+
+		It("Should requeue until the rate limit resets", func() {
+			By("setting the RateLimited condition", func() {
+				recorder := record.NewFakeRecorder(10)
+				controllerReconciler := &GithubIssueReconciler{
+					Client:   k8sClient,
+					Scheme:   k8sClient.Scheme(),
+					Tracker:  fakeTracker,
+					Recorder: recorder,
+				}
+
+				fakeTracker.SetRateLimitedUntil(time.Now().Add(time.Minute))
+				result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				fakeTracker.SetRateLimitedUntil(time.Time{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically(">", 50*time.Second))
+
+				resource := &assignmentcoreiov1.GithubIssue{}
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "RateLimited")).To(BeTrue())
+				Expect(meta.FindStatusCondition(resource.Status.Conditons, "Ready").Reason).To(Equal("RateLimited"))
+				Expect(recorder.Events).To(Receive(HavePrefix("Warning RateLimited")))
+
+				_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(k8sClient.Get(ctx, typeNamespacedName, resource)).To(Succeed())
+				Expect(meta.IsStatusConditionFalse(resource.Status.Conditons, "RateLimited")).To(BeTrue())
+				Expect(meta.IsStatusConditionTrue(resource.Status.Conditons, "Ready")).To(BeTrue())
+
+				rateLimitedConditions := 0
+				for _, condition := range resource.Status.Conditons {
+					if condition.Type == "RateLimited" {
+						rateLimitedConditions++
+					}
+				}
+				Expect(rateLimitedConditions).To(Equal(1))
 			})
 		})
 
 		It("Handle failed attempt to update remote issue", func() {
 			By("Update the issue object status", func() {
 				controllerReconciler := &GithubIssueReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
 				}
 
 				resource := &assignmentcoreiov1.GithubIssue{}
@@ -195,7 +788,7 @@ var _ = Describe("GithubIssue Controller", func() {
 					Expect(err).To(HaveOccurred())
 					err = k8sClient.Get(ctx, types.NamespacedName{Namespace: resource.Namespace, Name: resource.Name}, updatedResource)
 					Expect(err).NotTo(HaveOccurred())
-					return (controllerReconciler.containsCondition(updatedResource, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False") || controllerReconciler.containsCondition(updatedResource, "IssueOpen", "False"))
+					return (meta.IsStatusConditionFalse(updatedResource.Status.Conditons, "UserUpdatedHisAccessTokenInSecretAndExistingRepo") || meta.IsStatusConditionFalse(updatedResource.Status.Conditons, "IssueOpen"))
 				}).Should(BeTrue())
 
 			})
@@ -204,8 +797,9 @@ var _ = Describe("GithubIssue Controller", func() {
 		It("Handle failed attemp to create remote issue", func() {
 			By("Representing correct status of issue not open", func() {
 				controllerReconciler := &GithubIssueReconciler{
-					Client: k8sClient,
-					Scheme: k8sClient.Scheme(),
+					Client:  k8sClient,
+					Scheme:  k8sClient.Scheme(),
+					Tracker: fakeTracker,
 				}
 				resource := &assignmentcoreiov1.GithubIssue{}
 				err := k8sClient.Get(ctx, typeNamespacedName, resource)
@@ -223,9 +817,44 @@ var _ = Describe("GithubIssue Controller", func() {
 					Expect(err).To(HaveOccurred())
 					err = k8sClient.Get(ctx, types.NamespacedName{Namespace: resource.Namespace, Name: resource.Name}, createdResource)
 					Expect(err).NotTo(HaveOccurred())
-					return (controllerReconciler.containsCondition(createdResource, "UserUpdatedHisAccessTokenInSecretAndExistingRepo", "False") || controllerReconciler.containsCondition(createdResource, "IssueOpen", "False"))
+					return (meta.IsStatusConditionFalse(createdResource.Status.Conditons, "UserUpdatedHisAccessTokenInSecretAndExistingRepo") || meta.IsStatusConditionFalse(createdResource.Status.Conditons, "IssueOpen"))
 				}).Should(BeTrue())
 			})
 		})
 	})
 })
+
+// failingStatusClient fails every status write.
+type failingStatusClient struct {
+	client.Client
+}
+
+func (c *failingStatusClient) Status() client.SubResourceWriter {
+	return &failingStatusWriter{SubResourceWriter: c.Client.Status()}
+}
+
+type failingStatusWriter struct {
+	client.SubResourceWriter
+}
+
+func (w *failingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	return fmt.Errorf("status writes are failing")
+}
+
+// histogramSamples sums the observations of every series of a histogram.
+func histogramSamples(histogram *prometheus.HistogramVec) uint64 {
+	registry := prometheus.NewRegistry()
+	Expect(registry.Register(histogram)).To(Succeed())
+
+	families, err := registry.Gather()
+	Expect(err).NotTo(HaveOccurred())
+
+	var samples uint64
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			samples += metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	return samples
+}
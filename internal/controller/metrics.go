@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+var (
+	issuesByPhaseDesc = prometheus.NewDesc("githubissue_objects", "GithubIssue objects by phase.", []string{"phase"}, nil)
+	issuesByRepoDesc  = prometheus.NewDesc("githubissue_repo_objects", "GithubIssue objects by repo.", []string{"repo"}, nil)
+)
+
+// issueCollector counts the GithubIssue objects per phase and per repo at scrape time,
+// reading them through the reconciler client so the gauges never drift from the cluster.
+type issueCollector struct {
+	reconciler *GithubIssueReconciler
+}
+
+var _ prometheus.Collector = &issueCollector{}
+
+func (c *issueCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- issuesByPhaseDesc
+	descs <- issuesByRepoDesc
+}
+
+func (c *issueCollector) Collect(collected chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	allIssues := &assignmentcoreiov1.GithubIssueList{}
+	err := c.reconciler.List(ctx, allIssues)
+
+	if err != nil {
+		ctrl.Log.WithName("metrics").Error(err, "Could not list the GithubIssues to collect metrics")
+		return
+	}
+
+	byPhase := map[assignmentcoreiov1.IssuePhase]int{
+		assignmentcoreiov1.IssuePhasePending:     0,
+		assignmentcoreiov1.IssuePhaseOpen:        0,
+		assignmentcoreiov1.IssuePhaseClosed:      0,
+		assignmentcoreiov1.IssuePhaseFailed:      0,
+		assignmentcoreiov1.IssuePhaseTerminating: 0,
+	}
+	byRepo := map[string]int{}
+
+	for i := range allIssues.Items {
+		phase := allIssues.Items[i].Status.Phase
+		if phase == "" {
+			phase = assignmentcoreiov1.IssuePhasePending
+		}
+
+		byPhase[phase]++
+		byRepo[metrics.RepoLabel(c.reconciler.repository(&allIssues.Items[i]))]++
+	}
+
+	for phase, count := range byPhase {
+		collected <- prometheus.MustNewConstMetric(issuesByPhaseDesc, prometheus.GaugeValue, float64(count), string(phase))
+	}
+
+	for repo, count := range byRepo {
+		collected <- prometheus.MustNewConstMetric(issuesByRepoDesc, prometheus.GaugeValue, float64(count), repo)
+	}
+}
+
+// lifecycleObservation is a lifecycle histogram to observe with a remote timestamp once the status holding it is written.
+type lifecycleObservation struct {
+	histogram *prometheus.HistogramVec
+	at        time.Time
+}
+
+// recordIssueLifecycle stores when the remote issue was opened and closed in the status and returns the lifecycle
+// observations of the timestamps it sets for the first time, the status keeps a restarted operator from observing them again.
+// Reopening clears ClosedAt so every close of the issue is observed.
+func (r *GithubIssueReconciler) recordIssueLifecycle(githubIssueInstance *assignmentcoreiov1.GithubIssue, remoteIssue *tracker.Issue) []lifecycleObservation {
+	var observations []lifecycleObservation
+
+	if githubIssueInstance.Status.OpenedAt == nil && !remoteIssue.CreatedAt.IsZero() {
+		githubIssueInstance.Status.OpenedAt = &metav1.Time{Time: remoteIssue.CreatedAt}
+		observations = append(observations, lifecycleObservation{histogram: metrics.IssueTimeToOpen, at: remoteIssue.CreatedAt})
+	}
+
+	if remoteIssue.State != "closed" || remoteIssue.ClosedAt == nil {
+		githubIssueInstance.Status.ClosedAt = nil
+	} else if githubIssueInstance.Status.ClosedAt == nil {
+		githubIssueInstance.Status.ClosedAt = &metav1.Time{Time: *remoteIssue.ClosedAt}
+		observations = append(observations, lifecycleObservation{histogram: metrics.IssueTimeToClose, at: *remoteIssue.ClosedAt})
+	}
+
+	return observations
+}
+
+// recordFirstPullRequest stores when the first pull request was linked to the remote issue and returns its observation.
+func (r *GithubIssueReconciler) recordFirstPullRequest(githubIssueInstance *assignmentcoreiov1.GithubIssue, pullRequests []tracker.PullRequest) []lifecycleObservation {
+	if githubIssueInstance.Status.FirstPullRequestLinkedAt != nil {
+		return nil
+	}
+
+	var firstLinkedAt time.Time
+	for _, pullRequest := range pullRequests {
+		if !pullRequest.LinkedAt.IsZero() && (firstLinkedAt.IsZero() || pullRequest.LinkedAt.Before(firstLinkedAt)) {
+			firstLinkedAt = pullRequest.LinkedAt
+		}
+	}
+
+	if firstLinkedAt.IsZero() {
+		return nil
+	}
+
+	githubIssueInstance.Status.FirstPullRequestLinkedAt = &metav1.Time{Time: firstLinkedAt}
+	return []lifecycleObservation{{histogram: metrics.IssueTimeToFirstPullRequest, at: firstLinkedAt}}
+}
+
+// observeLifecycle observes the time from the creation of the object to each remote timestamp.
+// Issues adopted with a history older than the object say nothing about how fast it was handled and are skipped.
+func (r *GithubIssueReconciler) observeLifecycle(githubIssueInstance *assignmentcoreiov1.GithubIssue, observations []lifecycleObservation) {
+	repo := metrics.RepoLabel(r.repository(githubIssueInstance))
+
+	for _, observation := range observations {
+		elapsed := observation.at.Sub(githubIssueInstance.CreationTimestamp.Time)
+		if elapsed < 0 {
+			continue
+		}
+
+		observation.histogram.WithLabelValues(repo, githubIssueInstance.Namespace).Observe(elapsed.Seconds())
+	}
+}
@@ -33,7 +33,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
-	config "github.com/idoSharon1/githubIssue-operator/cmd/config"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/fake"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -43,6 +44,10 @@ import (
 var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
+var fakeTracker *fake.Tracker
+
+// testAccessToken is the only token the fake tracker accepts.
+const testAccessToken = "test-access-token"
 
 func TestControllers(t *testing.T) {
 	RegisterFailHandler(Fail)
@@ -73,9 +78,6 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = config.LoadEnvFile(".env")
-	Expect(err).NotTo(HaveOccurred())
-
 	err = assignmentcoreiov1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -85,6 +87,10 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
 
+	fakeTracker = fake.NewTracker()
+	fakeTracker.Token = tracker.ContextToken
+	fakeTracker.ValidToken = testAccessToken
+	fakeTracker.AddRepository(tracker.Repository{Host: assignmentcoreiov1.DefaultGithubHost, Owner: "idoSharon1", Name: "NamespaceLabel-operator"})
 })
 
 var _ = AfterSuite(func() {
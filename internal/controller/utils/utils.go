@@ -1,15 +1,66 @@
 package utils
 
 import (
-	"os"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
 )
 
-func SetEnvironmentVariable(key string, value string) error {
-	err := os.Setenv(key, value)
+var invalidLabelValueCharacters = regexp.MustCompile(`[^-A-Za-z0-9_.]+`)
+
+// DiffStrings returns the values of wanted missing from current and the values of current missing from wanted.
+func DiffStrings(wanted []string, current []string) (added []string, removed []string) {
+	currentSet := make(map[string]bool, len(current))
+	for _, value := range current {
+		currentSet[value] = true
+	}
+
+	wantedSet := make(map[string]bool, len(wanted))
+	for _, value := range wanted {
+		wantedSet[value] = true
+		if !currentSet[value] {
+			added = append(added, value)
+		}
+	}
+
+	for _, value := range current {
+		if !wantedSet[value] {
+			removed = append(removed, value)
+		}
+	}
+
+	return added, removed
+}
+
+// RepoLabelValue is the value of the repo helper label, shared by the reconciler and the webhook receiver.
+// Nested gitlab groups in the owner turn into dashes.
+func RepoLabelValue(owner string, repo string) string {
+	return ToLabelValue(fmt.Sprintf("%s.%s", owner, repo))
+}
 
-	if err != nil {
-		return err
-	} else {
-		return nil
+// RepoHost is the lowercased host of a repo URL, shared by the reconciler and the webhook receiver.
+// Repos without a host live on github.com.
+func RepoHost(repo string) string {
+	repoUrl, err := url.Parse(repo)
+	if err != nil || repoUrl.Host == "" {
+		return assignmentcoreiov1.DefaultGithubHost
 	}
+
+	return strings.ToLower(repoUrl.Host)
+}
+
+// ToLabelValue converts any string into a valid kubernetes label value, invalid characters become dashes.
+func ToLabelValue(value string) string {
+	labelValue := invalidLabelValueCharacters.ReplaceAllString(value, "-")
+
+	if len(labelValue) > validation.LabelValueMaxLength {
+		labelValue = labelValue[:validation.LabelValueMaxLength]
+	}
+
+	return strings.Trim(labelValue, "-_.")
 }
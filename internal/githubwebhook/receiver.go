@@ -0,0 +1,265 @@
+package githubwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
+	"github.com/idoSharon1/githubIssue-operator/internal/controller/utils"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+const (
+	EventHeader     = "X-GitHub-Event"
+	SignatureHeader = "X-Hub-Signature-256"
+	DeliveryHeader  = "X-GitHub-Delivery"
+	// EnterpriseHostHeader names the GitHub Enterprise Server host a delivery was sent from, github.com omits it.
+	EnterpriseHostHeader = "X-GitHub-Enterprise-Host"
+
+	signaturePrefix = "sha256="
+	// maxPayloadSize is the largest payload github sends, bigger deliveries are capped by github itself.
+	maxPayloadSize = 25 << 20
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Receiver accepts github webhook deliveries and turns them into generic events for the matching GithubIssue objects.
+type Receiver struct {
+	// Client reads the webhook secret and lists the GithubIssue objects.
+	Client client.Client
+	// Addr is the address the receiver binds to, "0" disables it.
+	Addr string
+	// Path the deliveries are posted to.
+	Path string
+	// Secret holds the shared secret configured on the github webhook under SecretKey.
+	Secret    types.NamespacedName
+	SecretKey string
+	// RepoLabelKey is the helper label the reconciler puts on every GithubIssue with its repo.
+	RepoLabelKey string
+	// Events is the channel the GithubIssue controller watches.
+	Events chan<- event.GenericEvent
+	// Invalidator is optional, it drops the cached issues of the delivery's repo before the objects are enqueued.
+	Invalidator tracker.Invalidator
+}
+
+// Start serves deliveries until the context is cancelled, it is run by the manager as a Runnable.
+func (r *Receiver) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("github-webhook")
+
+	if r.Addr == "" || r.Addr == "0" {
+		logger.Info("Github webhook receiver is disabled")
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(r.Path, r)
+
+	server := &http.Server{
+		Addr:              r.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(_ net.Listener) context.Context { return ctx },
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logger.Info("Starting github webhook receiver", "addr", r.Addr, "path", r.Path)
+		errChan <- server.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	case err := <-errChan:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+
+		return err
+	}
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	logger := log.FromContext(ctx).WithName("github-webhook").WithValues("delivery", req.Header.Get(DeliveryHeader))
+
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(req.Body, maxPayloadSize))
+
+	if err != nil {
+		http.Error(w, "could not read payload", http.StatusBadRequest)
+		return
+	}
+
+	secret, err := r.webhookSecret(ctx)
+
+	if err != nil {
+		logger.Error(err, "Could not load webhook secret")
+		http.Error(w, "webhook secret is not available", http.StatusInternalServerError)
+		return
+	}
+
+	err = ValidateSignature(secret, payload, req.Header.Get(SignatureHeader))
+
+	if err != nil {
+		logger.Info("Rejected delivery", "reason", err.Error())
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	eventType := req.Header.Get(EventHeader)
+
+	switch eventType {
+	case "issues", "issue_comment", "pull_request":
+	case "ping":
+		w.WriteHeader(http.StatusOK)
+		return
+	default:
+		logger.Info("Ignoring unsupported event", "event", eventType)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	var delivery Delivery
+	err = json.Unmarshal(payload, &delivery)
+
+	if err != nil || delivery.Repository == nil {
+		http.Error(w, "malformed payload", http.StatusBadRequest)
+		return
+	}
+
+	if r.Invalidator != nil {
+		r.Invalidator.Invalidate(tracker.Repository{Host: deliveryHost(req), Owner: delivery.Repository.Owner.Login, Name: delivery.Repository.Name})
+	}
+
+	githubIssues, err := r.affectedGithubIssues(ctx, deliveryHost(req), eventType, &delivery)
+
+	if err != nil {
+		logger.Error(err, "Could not list affected objects")
+		http.Error(w, "could not list affected objects", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range githubIssues {
+		select {
+		case r.Events <- event.GenericEvent{Object: &githubIssues[i]}:
+		case <-ctx.Done():
+			http.Error(w, "request cancelled", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	logger.Info("Enqueued objects for delivery", "event", eventType, "repo", delivery.Repository.FullName, "count", len(githubIssues))
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ValidateSignature checks the X-Hub-Signature-256 header against the HMAC of the payload.
+func ValidateSignature(secret []byte, payload []byte, signature string) error {
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(given, Sign(secret, payload)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// Sign returns the raw HMAC-SHA256 github puts (hex encoded) in the X-Hub-Signature-256 header.
+func Sign(secret []byte, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// deliveryHost is the host of the repository a delivery is about.
+func deliveryHost(req *http.Request) string {
+	if host := req.Header.Get(EnterpriseHostHeader); host != "" {
+		return strings.ToLower(host)
+	}
+
+	return assignmentcoreiov1.DefaultGithubHost
+}
+
+func (r *Receiver) webhookSecret(ctx context.Context) ([]byte, error) {
+	secret := &corev1.Secret{}
+	err := r.Client.Get(ctx, r.Secret, secret)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := secret.Data[r.SecretKey]
+
+	if !ok || len(value) == 0 {
+		return nil, fmt.Errorf("secret %s has no %q key", r.Secret, r.SecretKey)
+	}
+
+	return value, nil
+}
+
+// affectedGithubIssues returns the objects of the delivery's repo the event is about.
+// The repo label has no host so objects of repos with the same owner and name on other hosts are filtered out.
+// Pull request events can reference any issue of the repo so all of its objects are returned.
+func (r *Receiver) affectedGithubIssues(ctx context.Context, host string, eventType string, delivery *Delivery) ([]assignmentcoreiov1.GithubIssue, error) {
+	githubIssueList := &assignmentcoreiov1.GithubIssueList{}
+	err := r.Client.List(ctx, githubIssueList, client.MatchingLabels{
+		r.RepoLabelKey: utils.RepoLabelValue(delivery.Repository.Owner.Login, delivery.Repository.Name),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var repoGithubIssues []assignmentcoreiov1.GithubIssue
+	for _, githubIssue := range githubIssueList.Items {
+		if utils.RepoHost(githubIssue.Spec.Repo) == host {
+			repoGithubIssues = append(repoGithubIssues, githubIssue)
+		}
+	}
+
+	if eventType == "pull_request" || delivery.Issue == nil || delivery.Issue.PullRequest != nil {
+		return repoGithubIssues, nil
+	}
+
+	var affected []assignmentcoreiov1.GithubIssue
+	for _, githubIssue := range repoGithubIssues {
+		if githubIssue.Status.IssueNumber == delivery.Issue.Number ||
+			(githubIssue.Status.IssueNumber == 0 && githubIssue.Spec.Title == delivery.Issue.Title) {
+			affected = append(affected, githubIssue)
+		}
+	}
+
+	return affected, nil
+}
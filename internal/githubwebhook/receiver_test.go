@@ -0,0 +1,146 @@
+package githubwebhook_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	assignmentcoreiov1 "github.com/idoSharon1/githubIssue-operator/api/v1"
+	"github.com/idoSharon1/githubIssue-operator/internal/githubwebhook"
+)
+
+const webhookSecret = "webhook-secret"
+
+var _ = Describe("Github Webhook Receiver", func() {
+	var receiver *githubwebhook.Receiver
+	var events chan event.GenericEvent
+
+	githubIssue := func(name string, title string, issueNumber int) *assignmentcoreiov1.GithubIssue {
+		return &assignmentcoreiov1.GithubIssue{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"helper/repo": "owner.repo"},
+			},
+			Spec:   assignmentcoreiov1.GithubIssueSpec{Repo: "https://github.com/owner/repo", Title: title},
+			Status: assignmentcoreiov1.GithubIssueStatus{IssueNumber: issueNumber},
+		}
+	}
+
+	enterpriseGithubIssue := func(name string, title string, issueNumber int) *assignmentcoreiov1.GithubIssue {
+		return &assignmentcoreiov1.GithubIssue{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{"helper/repo": "owner.repo"},
+			},
+			Spec:   assignmentcoreiov1.GithubIssueSpec{Repo: "https://GitHub.example.com/owner/repo", Title: title},
+			Status: assignmentcoreiov1.GithubIssueStatus{IssueNumber: issueNumber},
+		}
+	}
+
+	deliverFrom := func(host string, eventType string, payload string, secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/github/webhook", bytes.NewBufferString(payload))
+		if host != "" {
+			req.Header.Set(githubwebhook.EnterpriseHostHeader, host)
+		}
+		req.Header.Set(githubwebhook.EventHeader, eventType)
+		req.Header.Set(githubwebhook.SignatureHeader, "sha256="+hex.EncodeToString(githubwebhook.Sign([]byte(secret), []byte(payload))))
+
+		recorder := httptest.NewRecorder()
+		receiver.ServeHTTP(recorder, req)
+
+		return recorder
+	}
+
+	deliver := func(eventType string, payload string, secret string) *httptest.ResponseRecorder {
+		return deliverFrom("", eventType, payload, secret)
+	}
+
+	receivedNames := func() []string {
+		var names []string
+		for len(events) > 0 {
+			names = append(names, (<-events).Object.GetName())
+		}
+
+		return names
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(assignmentcoreiov1.AddToScheme(scheme)).To(Succeed())
+
+		k8sClient := fakeclient.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "github-webhook-secret", Namespace: "operator"},
+				Data:       map[string][]byte{"secret": []byte(webhookSecret)},
+			},
+			githubIssue("first", "first issue", 1),
+			githubIssue("second", "second issue", 2),
+			githubIssue("pending", "pending issue", 0),
+			enterpriseGithubIssue("enterprise", "second issue", 2),
+		).Build()
+
+		events = make(chan event.GenericEvent, 10)
+		receiver = &githubwebhook.Receiver{
+			Client:       k8sClient,
+			Path:         "/github/webhook",
+			Secret:       types.NamespacedName{Namespace: "operator", Name: "github-webhook-secret"},
+			SecretKey:    "secret",
+			RepoLabelKey: "helper/repo",
+			Events:       events,
+		}
+	})
+
+	It("should reject deliveries with a bad signature", func() {
+		recorder := deliver("issues", `{"repository": {"name": "repo", "owner": {"login": "owner"}}, "issue": {"number": 1}}`, "wrong-secret")
+		Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		Expect(events).To(BeEmpty())
+	})
+
+	It("should enqueue the object matching the issue number", func() {
+		recorder := deliver("issues", `{"action": "edited", "repository": {"name": "repo", "owner": {"login": "owner"}}, "issue": {"number": 2, "title": "second issue"}}`, webhookSecret)
+		Expect(recorder.Code).To(Equal(http.StatusAccepted))
+		Expect(receivedNames()).To(Equal([]string{"second"}))
+	})
+
+	It("should match objects without an issue number by title", func() {
+		recorder := deliver("issue_comment", `{"action": "created", "repository": {"name": "repo", "owner": {"login": "owner"}}, "issue": {"number": 9, "title": "pending issue"}}`, webhookSecret)
+		Expect(recorder.Code).To(Equal(http.StatusAccepted))
+		Expect(receivedNames()).To(Equal([]string{"pending"}))
+	})
+
+	It("should enqueue every object of the repo on pull request events", func() {
+		recorder := deliver("pull_request", `{"action": "opened", "repository": {"name": "repo", "owner": {"login": "owner"}}, "pull_request": {"number": 10}}`, webhookSecret)
+		Expect(recorder.Code).To(Equal(http.StatusAccepted))
+		Expect(receivedNames()).To(ConsistOf("first", "second", "pending"))
+	})
+
+	It("should only enqueue objects of the delivery's host", func() {
+		payload := `{"action": "edited", "repository": {"name": "repo", "owner": {"login": "owner"}}, "issue": {"number": 2, "title": "second issue"}}`
+		Expect(deliverFrom("github.example.com", "issues", payload, webhookSecret).Code).To(Equal(http.StatusAccepted))
+		Expect(receivedNames()).To(Equal([]string{"enterprise"}))
+
+		Expect(deliverFrom("github.example.com", "pull_request", `{"action": "opened", "repository": {"name": "repo", "owner": {"login": "owner"}}, "pull_request": {"number": 10}}`, webhookSecret).Code).To(Equal(http.StatusAccepted))
+		Expect(receivedNames()).To(Equal([]string{"enterprise"}))
+	})
+
+	It("should ignore deliveries of other repos and events", func() {
+		Expect(deliver("issues", `{"repository": {"name": "other", "owner": {"login": "owner"}}, "issue": {"number": 1}}`, webhookSecret).Code).To(Equal(http.StatusAccepted))
+		Expect(deliver("push", `{"repository": {"name": "repo", "owner": {"login": "owner"}}}`, webhookSecret).Code).To(Equal(http.StatusAccepted))
+		Expect(deliver("ping", `{"zen": "Keep it logically awesome."}`, webhookSecret).Code).To(Equal(http.StatusOK))
+		Expect(events).To(BeEmpty())
+	})
+})
@@ -0,0 +1,31 @@
+package githubwebhook
+
+// Delivery is the subset of the issues, issue_comment and pull_request payloads needed to find the affected objects.
+type Delivery struct {
+	Action      string       `json:"action"`
+	Repository  *Repository  `json:"repository"`
+	Issue       *Issue       `json:"issue"`
+	PullRequest *PullRequest `json:"pull_request"`
+}
+
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+}
+
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	// PullRequest is set when the issue_comment was left on a pull request.
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request"`
+}
+
+type PullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+const namespace = "githubissue"
+
+var (
+	// APIRequests counts the requests sent to the remote trackers.
+	APIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "Requests sent to the remote issue trackers by host, endpoint, method and status code.",
+	}, []string{"host", "endpoint", "method", "status"})
+
+	// APIRequestDuration observes the latency of the requests sent to the remote trackers.
+	APIRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of the requests sent to the remote issue trackers by host, endpoint and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host", "endpoint", "method"})
+
+	// APIErrors counts the failed requests by class, see the Error* constants.
+	APIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "Failed requests to the remote issue trackers by host and error class.",
+	}, []string{"host", "class"})
+
+	// RateLimitRemaining is the github quota left as of the last response, set by the github client.
+	RateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "github_rate_limit_remaining",
+		Help:      "Remaining github API requests in the rate limit window of the last response, per host and resource.",
+	}, []string{"host", "resource"})
+
+	IssuesCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "issues_created_total",
+		Help:      "Remote issues opened by the operator.",
+	}, []string{"repo"})
+
+	IssuesUpdated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "issues_updated_total",
+		Help:      "Remote issues edited by the operator, closing and reopening included.",
+	}, []string{"repo"})
+
+	IssuesClosed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "issues_closed_total",
+		Help:      "Remote issues closed by the operator.",
+	}, []string{"repo"})
+
+	// IssueTimeToOpen, IssueTimeToFirstPullRequest and IssueTimeToClose observe the issue lifecycle from the creation
+	// of the GithubIssue object, they are computed from the remote timestamps so downtime of the operator does not skew them.
+	// They are observed once the status recording the timestamp is written.
+	IssueTimeToOpen = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "issue_time_to_open_seconds",
+		Help:      "Time from the creation of a GithubIssue to the remote issue being opened.",
+		// 1s to about 3 days
+		Buckets: prometheus.ExponentialBuckets(1, 4, 10),
+	}, []string{"repo", "namespace"})
+
+	IssueTimeToFirstPullRequest = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "issue_time_to_first_pull_request_seconds",
+		Help:      "Time from the creation of a GithubIssue to the first pull request linked to its remote issue.",
+		// 1m to about half a year
+		Buckets: prometheus.ExponentialBuckets(60, 4, 10),
+	}, []string{"repo", "namespace"})
+
+	IssueTimeToClose = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "issue_time_to_close_seconds",
+		Help:      "Time from the creation of a GithubIssue to its remote issue being closed, every close is observed when an issue is reopened and closed again.",
+		Buckets:   prometheus.ExponentialBuckets(60, 4, 10),
+	}, []string{"repo", "namespace"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(APIRequests, APIRequestDuration, APIErrors, RateLimitRemaining, IssuesCreated, IssuesUpdated, IssuesClosed,
+		IssueTimeToOpen, IssueTimeToFirstPullRequest, IssueTimeToClose)
+}
+
+// RepoLabel is the repo label value of a repository, the host is kept so repos of different hosts never share a series.
+func RepoLabel(repo tracker.Repository) string {
+	return repo.Host + "/" + repo.String()
+}
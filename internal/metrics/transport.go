@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Error classes of APIErrors.
+const (
+	ErrorTransport   = "transport"
+	ErrorAuth        = "auth"
+	ErrorForbidden   = "forbidden"
+	ErrorNotFound    = "not_found"
+	ErrorRateLimited = "rate_limited"
+	ErrorClient      = "client"
+	ErrorServer      = "server"
+)
+
+// Transport records every request it sends in APIRequests, APIRequestDuration and APIErrors.
+type Transport struct {
+	// Host is the configured host the requests are recorded under.
+	Host string
+	// Next sends the requests, defaults to http.DefaultTransport.
+	Next http.RoundTripper
+}
+
+func NewTransport(host string, next http.RoundTripper) *Transport {
+	return &Transport{Host: host, Next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	endpoint := Endpoint(req.URL.EscapedPath())
+
+	start := time.Now()
+	res, err := next.RoundTrip(req)
+	APIRequestDuration.WithLabelValues(t.Host, endpoint, req.Method).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		APIRequests.WithLabelValues(t.Host, endpoint, req.Method, "error").Inc()
+		APIErrors.WithLabelValues(t.Host, ErrorTransport).Inc()
+		return nil, err
+	}
+
+	APIRequests.WithLabelValues(t.Host, endpoint, req.Method, strconv.Itoa(res.StatusCode)).Inc()
+	if class := errorClass(res); class != "" {
+		APIErrors.WithLabelValues(t.Host, class).Inc()
+	}
+
+	return res, nil
+}
+
+// Endpoint turns a request path into a low cardinality label: repository owners and names, gitlab project paths,
+// jira issue keys and numbers (but API versions) are replaced by placeholders (/repos/o/r/issues/12 becomes /repos/{owner}/{repo}/issues/{number}).
+func Endpoint(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i := 0; i < len(segments); i++ {
+		switch {
+		case segments[i] == "repos" && i+2 < len(segments):
+			segments[i+1], segments[i+2] = "{owner}", "{repo}"
+			i += 2
+		case segments[i] == "projects" && i+1 < len(segments):
+			segments[i+1] = "{project}"
+			i++
+		case segments[i] == "issue" && i+1 < len(segments):
+			segments[i+1] = "{key}"
+			i++
+		case isNumber(segments[i]) && (i == 0 || segments[i-1] != "api"):
+			segments[i] = "{number}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// errorClass classifies a response, it returns "" for successful and not modified responses.
+// github answers an exhausted quota with 403 and X-RateLimit-Remaining 0 instead of 429.
+func errorClass(res *http.Response) string {
+	switch {
+	case res.StatusCode < 400:
+		return ""
+	case res.StatusCode == http.StatusTooManyRequests,
+		res.StatusCode == http.StatusForbidden && res.Header.Get("X-RateLimit-Remaining") == "0":
+		return ErrorRateLimited
+	case res.StatusCode == http.StatusUnauthorized:
+		return ErrorAuth
+	case res.StatusCode == http.StatusForbidden:
+		return ErrorForbidden
+	case res.StatusCode == http.StatusNotFound:
+		return ErrorNotFound
+	case res.StatusCode >= 500:
+		return ErrorServer
+	default:
+		return ErrorClient
+	}
+}
+
+func isNumber(segment string) bool {
+	if segment == "" {
+		return false
+	}
+
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
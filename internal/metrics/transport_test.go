@@ -0,0 +1,61 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+)
+
+var _ = Describe("Transport", func() {
+	var server *httptest.Server
+	var client *http.Client
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/repos/owner/repo/issues/1":
+				w.WriteHeader(http.StatusOK)
+			case "/repos/owner/repo/issues/2":
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.WriteHeader(http.StatusForbidden)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		client = &http.Client{Transport: metrics.NewTransport("transport.test", nil)}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should template the endpoints", func() {
+		Expect(metrics.Endpoint("/api/v3/repos/owner/repo/issues/12/comments")).To(Equal("/api/v3/repos/{owner}/{repo}/issues/{number}/comments"))
+		Expect(metrics.Endpoint("/api/v4/projects/group%2Fsub%2Fproject/issues/3")).To(Equal("/api/v4/projects/{project}/issues/{number}"))
+		Expect(metrics.Endpoint("/rest/api/2/issue/PROJ-12/transitions")).To(Equal("/rest/api/2/issue/{key}/transitions"))
+		Expect(metrics.Endpoint("/app/installations/42/access_tokens")).To(Equal("/app/installations/{number}/access_tokens"))
+	})
+
+	It("should count requests by status and errors by class", func() {
+		for _, path := range []string{"/repos/owner/repo/issues/1", "/repos/owner/repo/issues/1", "/repos/owner/repo/issues/2", "/missing"} {
+			res, err := client.Get(server.URL + path)
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+		}
+
+		Expect(testutil.ToFloat64(metrics.APIRequests.WithLabelValues("transport.test", "/repos/{owner}/{repo}/issues/{number}", "GET", "200"))).To(Equal(2.0))
+		Expect(testutil.ToFloat64(metrics.APIRequests.WithLabelValues("transport.test", "/repos/{owner}/{repo}/issues/{number}", "GET", "403"))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(metrics.APIErrors.WithLabelValues("transport.test", metrics.ErrorRateLimited))).To(Equal(1.0))
+		Expect(testutil.ToFloat64(metrics.APIErrors.WithLabelValues("transport.test", metrics.ErrorNotFound))).To(Equal(1.0))
+		Expect(testutil.CollectAndCount(metrics.APIRequestDuration, "githubissue_api_request_duration_seconds")).To(BeNumerically(">=", 2))
+
+		_, err := client.Get("http://127.0.0.1:1/repos/owner/repo/issues")
+		Expect(err).To(HaveOccurred())
+		Expect(testutil.ToFloat64(metrics.APIErrors.WithLabelValues("transport.test", metrics.ErrorTransport))).To(Equal(1.0))
+	})
+})
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of every span of the operator.
+const TracerName = "github.com/idoSharon1/githubIssue-operator"
+
+// Options configure the OTLP exporter.
+type Options struct {
+	// Endpoint is the host:port of the OTLP/HTTP collector, tracing is disabled when it is empty.
+	Endpoint string
+	// Insecure sends the spans over plain http.
+	Insecure bool
+	// SampleRatio is the ratio of the traces started by the operator that are sampled, traces started by a parent keep its decision.
+	SampleRatio float64
+	// ServiceName is recorded as the service.name of the spans.
+	ServiceName string
+}
+
+// Setup installs a global tracer provider exporting to the configured collector and returns the function flushing
+// and stopping it. When no endpoint is configured the global no-op provider is kept and the returned function does nothing.
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	if options.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporterOptions := []otlptracehttp.Option{otlptracehttp.WithEndpoint(options.Endpoint)}
+	if options.Insecure {
+		exporterOptions = append(exporterOptions, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOptions...)
+
+	if err != nil {
+		return nil, fmt.Errorf("could not create the OTLP exporter: %w", err)
+	}
+
+	serviceName := options.ServiceName
+	if serviceName == "" {
+		serviceName = "githubissue-operator"
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer is the tracer of the global provider, it is looked up on every call so a provider installed later is picked up.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,157 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracing"
+)
+
+// collector is an in-process OTLP/HTTP collector keeping the spans it receives.
+type collector struct {
+	lock  sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.lock.Lock()
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+	c.lock.Unlock()
+
+	response, _ := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	_, _ = w.Write(response)
+}
+
+func (c *collector) span(name string) *tracepb.Span {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, span := range c.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+
+	return nil
+}
+
+func attributes(span *tracepb.Span) map[string]interface{} {
+	values := map[string]interface{}{}
+	for _, attribute := range span.Attributes {
+		switch value := attribute.Value.Value.(type) {
+		case *commonpb.AnyValue_StringValue:
+			values[attribute.Key] = value.StringValue
+		case *commonpb.AnyValue_IntValue:
+			values[attribute.Key] = value.IntValue
+		}
+	}
+
+	return values
+}
+
+var _ = Describe("Tracing", func() {
+	var spans *collector
+	var collectorServer *httptest.Server
+	var githubServer *httptest.Server
+
+	BeforeEach(func() {
+		spans = &collector{}
+		collectorServer = httptest.NewServer(spans)
+		githubServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/repos/owner/repo/issues/1":
+				w.Header().Set("X-RateLimit-Remaining", "4999")
+				w.Header().Set("X-RateLimit-Reset", "1700000000")
+				w.WriteHeader(http.StatusOK)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		collectorServer.Close()
+		githubServer.Close()
+	})
+
+	It("should be disabled without an endpoint", func() {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(shutdown(context.Background())).To(Succeed())
+	})
+
+	It("should export the request spans as children of the reconcile span", func() {
+		shutdown, err := tracing.Setup(context.Background(), tracing.Options{
+			Endpoint:    strings.TrimPrefix(collectorServer.URL, "http://"),
+			Insecure:    true,
+			SampleRatio: 1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		client := &http.Client{Transport: tracing.NewTransport(nil)}
+		ctx, reconcileSpan := tracing.Start(context.Background(), "Reconcile")
+
+		for _, path := range []string{"/repos/owner/repo/issues/1", "/missing"} {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, githubServer.URL+path, bytes.NewReader(nil))
+			Expect(err).NotTo(HaveOccurred())
+
+			res, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			res.Body.Close()
+		}
+
+		reconcileSpan.End()
+		Expect(shutdown(context.Background())).To(Succeed())
+
+		reconcile := spans.span("Reconcile")
+		Expect(reconcile).NotTo(BeNil())
+
+		request := spans.span("GET /repos/{owner}/{repo}/issues/{number}")
+		Expect(request).NotTo(BeNil())
+		Expect(request.TraceId).To(Equal(reconcile.TraceId))
+		Expect(request.ParentSpanId).To(Equal(reconcile.SpanId))
+		Expect(request.Kind).To(Equal(tracepb.Span_SPAN_KIND_CLIENT))
+		Expect(attributes(request)).To(SatisfyAll(
+			HaveKeyWithValue("http.request.method", "GET"),
+			HaveKeyWithValue("url.path", "/repos/owner/repo/issues/1"),
+			HaveKeyWithValue("http.response.status_code", int64(200)),
+			HaveKeyWithValue("ratelimit.remaining", "4999"),
+			HaveKeyWithValue("ratelimit.reset", "1700000000"),
+		))
+		Expect(request.Status.GetCode()).NotTo(Equal(tracepb.Status_STATUS_CODE_ERROR))
+
+		missing := spans.span("GET /missing")
+		Expect(missing).NotTo(BeNil())
+		Expect(attributes(missing)).To(HaveKeyWithValue("http.response.status_code", int64(404)))
+		Expect(missing.Status.GetCode()).To(Equal(tracepb.Status_STATUS_CODE_ERROR))
+	})
+})
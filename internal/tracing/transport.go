@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+)
+
+// rateLimitHeaders are recorded on the request spans: github and gitea send the X-RateLimit-* headers,
+// gitlab the RateLimit-* ones and every tracker may send Retry-After with a 429.
+var rateLimitHeaders = map[string]attribute.Key{
+	"X-RateLimit-Limit":     "ratelimit.limit",
+	"X-RateLimit-Remaining": "ratelimit.remaining",
+	"X-RateLimit-Reset":     "ratelimit.reset",
+	"X-RateLimit-Resource":  "ratelimit.resource",
+	"RateLimit-Limit":       "ratelimit.limit",
+	"RateLimit-Remaining":   "ratelimit.remaining",
+	"RateLimit-Reset":       "ratelimit.reset",
+	"Retry-After":           "ratelimit.retry_after",
+}
+
+// Transport sends every request in a client span that is a child of the span in the request context.
+type Transport struct {
+	// Next sends the requests, defaults to http.DefaultTransport.
+	Next http.RoundTripper
+}
+
+func NewTransport(next http.RoundTripper) *Transport {
+	return &Transport{Next: next}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	// The trace context is not propagated, the remote trackers are third parties that do not take part in the trace
+	_, span := Tracer().Start(req.Context(), req.Method+" "+metrics.Endpoint(req.URL.EscapedPath()),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.EscapedPath()),
+		))
+	defer span.End()
+
+	res, err := next.RoundTrip(req)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	for header, key := range rateLimitHeaders {
+		if value := res.Header.Get(header); value != "" {
+			span.SetAttributes(key.String(value))
+		}
+	}
+
+	if res.StatusCode >= 400 {
+		span.SetStatus(codes.Error, res.Status)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,275 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// Options configures a Tracker.
+type Options struct {
+	// TTL is how long a repository index is served before it is listed again.
+	TTL time.Duration
+	// Token is the same token source the wrapped tracker uses, indexes are never shared between tokens
+	// so a token cannot see issues through another token's index. Indexes not used within the TTL are dropped,
+	// which keeps rotated tokens from leaving their indexes behind.
+	Token tracker.TokenSource
+}
+
+// Tracker wraps an IssueTracker with an in-memory index of every issue of a repository,
+// shared by all reconciles so objects pointing at the same repository cost one list call per TTL.
+type Tracker struct {
+	inner tracker.IssueTracker
+	ttl   time.Duration
+	token tracker.TokenSource
+
+	mu      sync.Mutex
+	indexes map[indexKey]*index
+	// sweptAt is when the unused indexes were last dropped.
+	sweptAt time.Time
+}
+
+type indexKey struct {
+	repo  tracker.Repository
+	token string
+}
+
+// index holds the issues of a repository in every state, its lock is held while it refreshes
+// so concurrent reconciles of the same repository wait for a single list call.
+type index struct {
+	mu          sync.Mutex
+	issues      map[int]tracker.Issue
+	refreshedAt time.Time
+
+	// usedAt is guarded by the Tracker lock.
+	usedAt time.Time
+}
+
+var _ tracker.IssueTracker = &Tracker{}
+
+func New(inner tracker.IssueTracker, options Options) *Tracker {
+	return &Tracker{
+		inner:   inner,
+		ttl:     options.TTL,
+		token:   options.Token,
+		indexes: map[indexKey]*index{},
+	}
+}
+
+// Invalidate drops every index of repo, the next call lists it again.
+func (t *Tracker) Invalidate(repo tracker.Repository) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key := range t.indexes {
+		if key.repo == repo {
+			delete(t.indexes, key)
+		}
+	}
+}
+
+func (t *Tracker) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	state := options.State
+	if state == "" {
+		state = "open"
+	}
+
+	repoIndex, err := t.freshIndex(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer repoIndex.mu.Unlock()
+
+	var issues []tracker.Issue
+	for _, issue := range repoIndex.issues {
+		if state == "all" || issue.State == state {
+			issues = append(issues, issue)
+		}
+	}
+
+	// github lists the newest issues first
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number > issues[j].Number })
+
+	return issues, nil
+}
+
+// GetIssue answers from the index, issues opened after the last refresh are fetched and added to it.
+func (t *Tracker) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	repoIndex, err := t.freshIndex(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	issue, ok := repoIndex.issues[number]
+	repoIndex.mu.Unlock()
+
+	if ok {
+		return &issue, nil
+	}
+
+	remoteIssue, err := t.inner.GetIssue(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(ctx, repo, remoteIssue)
+
+	return remoteIssue, nil
+}
+
+func (t *Tracker) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	issue, err := t.inner.CreateIssue(ctx, repo, request)
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(ctx, repo, issue)
+
+	return issue, nil
+}
+
+func (t *Tracker) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	issue, err := t.inner.UpdateIssue(ctx, repo, number, request)
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(ctx, repo, issue)
+
+	return issue, nil
+}
+
+func (t *Tracker) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	issue, err := t.inner.CloseIssue(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	t.store(ctx, repo, issue)
+
+	return issue, nil
+}
+
+func (t *Tracker) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	return t.inner.ListLinkedPullRequests(ctx, repo, number)
+}
+
+func (t *Tracker) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	return t.inner.CreateComment(ctx, repo, number, body)
+}
+
+// LockIssue forgets the cached issue since the lock call does not return it.
+func (t *Tracker) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	err := t.inner.LockIssue(ctx, repo, number)
+
+	if repoIndex := t.existingIndex(ctx, repo); repoIndex != nil {
+		repoIndex.mu.Lock()
+		delete(repoIndex.issues, number)
+		repoIndex.mu.Unlock()
+	}
+
+	return err
+}
+
+// freshIndex returns the locked index of repo, listing the repository first when the index is older than the TTL.
+// Callers have to unlock it.
+func (t *Tracker) freshIndex(ctx context.Context, repo tracker.Repository) (*index, error) {
+	key, err := t.indexKey(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.sweep()
+	repoIndex, ok := t.indexes[key]
+	if !ok {
+		repoIndex = &index{}
+		t.indexes[key] = repoIndex
+	}
+	repoIndex.usedAt = time.Now()
+	t.mu.Unlock()
+
+	repoIndex.mu.Lock()
+
+	if repoIndex.issues != nil && time.Since(repoIndex.refreshedAt) < t.ttl {
+		return repoIndex, nil
+	}
+
+	issues, err := t.inner.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+	if err != nil {
+		repoIndex.mu.Unlock()
+		return nil, err
+	}
+
+	repoIndex.issues = make(map[int]tracker.Issue, len(issues))
+	for _, issue := range issues {
+		repoIndex.issues[issue.Number] = issue
+	}
+	repoIndex.refreshedAt = time.Now()
+
+	return repoIndex, nil
+}
+
+// sweep drops the indexes not used within the TTL, at most once per TTL, an index that old would be listed again anyway.
+// The Tracker lock has to be held.
+func (t *Tracker) sweep() {
+	now := time.Now()
+	if now.Sub(t.sweptAt) < t.ttl {
+		return
+	}
+
+	for key, repoIndex := range t.indexes {
+		if now.Sub(repoIndex.usedAt) >= t.ttl {
+			delete(t.indexes, key)
+		}
+	}
+	t.sweptAt = now
+}
+
+// existingIndex returns the index of repo without refreshing it, nil when it was never listed.
+func (t *Tracker) existingIndex(ctx context.Context, repo tracker.Repository) *index {
+	key, err := t.indexKey(ctx, repo)
+	if err != nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.indexes[key]
+}
+
+// store writes an issue returned by the remote into the index of repo, if there is one.
+func (t *Tracker) store(ctx context.Context, repo tracker.Repository, issue *tracker.Issue) {
+	repoIndex := t.existingIndex(ctx, repo)
+	if repoIndex == nil {
+		return
+	}
+
+	repoIndex.mu.Lock()
+	defer repoIndex.mu.Unlock()
+
+	if repoIndex.issues != nil {
+		repoIndex.issues[issue.Number] = *issue
+	}
+}
+
+func (t *Tracker) indexKey(ctx context.Context, repo tracker.Repository) (indexKey, error) {
+	if t.token == nil {
+		return indexKey{repo: repo}, nil
+	}
+
+	token, err := t.token(ctx)
+	if err != nil {
+		return indexKey{}, err
+	}
+
+	sum := sha256.Sum256([]byte(token))
+
+	return indexKey{repo: repo, token: hex.EncodeToString(sum[:])}, nil
+}
@@ -0,0 +1,114 @@
+package cache_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/cache"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/fake"
+)
+
+// countingTracker counts the list calls reaching the wrapped tracker.
+type countingTracker struct {
+	*fake.Tracker
+	lists int
+}
+
+func (c *countingTracker) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	c.lists++
+	return c.Tracker.ListIssues(ctx, repo, options)
+}
+
+type tokenKey struct{}
+
+var _ = Describe("Issue Cache", func() {
+	var inner *countingTracker
+	var cached *cache.Tracker
+	repo := tracker.Repository{Owner: "owner", Name: "repo"}
+	ctx := context.WithValue(context.Background(), tokenKey{}, "first-token")
+
+	BeforeEach(func() {
+		inner = &countingTracker{Tracker: fake.NewTracker()}
+		inner.AddRepository(repo)
+		cached = cache.New(inner, cache.Options{
+			TTL: time.Minute,
+			Token: func(ctx context.Context) (string, error) {
+				return ctx.Value(tokenKey{}).(string), nil
+			},
+		})
+	})
+
+	It("should list a repo once per TTL for every caller", func() {
+		_, err := inner.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("first")})
+		Expect(err).NotTo(HaveOccurred())
+
+		for i := 0; i < 10; i++ {
+			issues, err := cached.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+
+			issue, err := cached.GetIssue(ctx, repo, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue.Title).To(Equal("first"))
+		}
+
+		Expect(inner.lists).To(Equal(1))
+	})
+
+	It("should keep the index up to date with its own writes", func() {
+		_, err := cached.ListIssues(ctx, repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		created, err := cached.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("new")})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cached.CloseIssue(ctx, repo, created.Number)
+		Expect(err).NotTo(HaveOccurred())
+
+		openIssues, err := cached.ListIssues(ctx, repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(openIssues).To(BeEmpty())
+
+		closedIssues, err := cached.ListIssues(ctx, repo, tracker.ListOptions{State: "closed"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(closedIssues).To(HaveLen(1))
+		Expect(inner.lists).To(Equal(1))
+	})
+
+	It("should not share an index between tokens and relist after invalidation", func() {
+		_, err := cached.ListIssues(ctx, repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = cached.ListIssues(context.WithValue(context.Background(), tokenKey{}, "second-token"), repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inner.lists).To(Equal(2))
+
+		cached.Invalidate(repo)
+		_, err = cached.ListIssues(ctx, repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inner.lists).To(Equal(3))
+	})
+
+	It("should drop the indexes of tokens not used within the TTL", func() {
+		cached = cache.New(inner, cache.Options{
+			TTL: 20 * time.Millisecond,
+			Token: func(ctx context.Context) (string, error) {
+				return ctx.Value(tokenKey{}).(string), nil
+			},
+		})
+
+		for _, token := range []string{"first-token", "second-token", "third-token"} {
+			_, err := cached.ListIssues(context.WithValue(context.Background(), tokenKey{}, token), repo, tracker.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(cached.Indexes()).To(Equal(3))
+
+		time.Sleep(30 * time.Millisecond)
+		_, err := cached.ListIssues(context.WithValue(context.Background(), tokenKey{}, "rotated-token"), repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cached.Indexes()).To(Equal(1))
+	})
+})
@@ -0,0 +1,9 @@
+package cache
+
+// Indexes is the number of indexes held by the cache.
+func (t *Tracker) Indexes() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.indexes)
+}
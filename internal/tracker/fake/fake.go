@@ -0,0 +1,327 @@
+package fake
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// Tracker is an in-memory tracker.IssueTracker used to run the controller tests offline.
+// Repositories have to be registered with AddRepository, any other repository answers with tracker.ErrNotFound.
+type Tracker struct {
+	// Token and ValidToken are optional, when both are set every call whose token differs from ValidToken fails with tracker.ErrBadCredentials.
+	Token      tracker.TokenSource
+	ValidToken string
+
+	mu    sync.Mutex
+	repos map[tracker.Repository]*repository
+	// rateLimitedUntil makes every call fail with a tracker.RateLimitError while it is in the future.
+	rateLimitedUntil time.Time
+}
+
+type repository struct {
+	issues     map[int]*tracker.Issue
+	pulls      map[int][]tracker.PullRequest
+	comments   map[int][]string
+	nextNumber int
+}
+
+var _ tracker.IssueTracker = &Tracker{}
+
+func NewTracker() *Tracker {
+	return &Tracker{repos: map[tracker.Repository]*repository{}}
+}
+
+// AddRepository makes repo known to the tracker, it is a no-op if the repository already exists.
+func (t *Tracker) AddRepository(repo tracker.Repository) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.repos[repo]; !ok {
+		t.repos[repo] = &repository{
+			issues:     map[int]*tracker.Issue{},
+			pulls:      map[int][]tracker.PullRequest{},
+			comments:   map[int][]string{},
+			nextNumber: 1,
+		}
+	}
+}
+
+// LinkPullRequest links a pull request to an existing issue, linking the same pull request again replaces it.
+// A zero LinkedAt is set to the current time.
+func (t *Tracker) LinkPullRequest(repo tracker.Repository, number int, pullRequest tracker.PullRequest) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remote, ok := t.repos[repo]
+	if !ok {
+		return
+	}
+
+	if pullRequest.LinkedAt.IsZero() {
+		pullRequest.LinkedAt = time.Now()
+	}
+
+	for i, linked := range remote.pulls[number] {
+		if linked.Repository == pullRequest.Repository && linked.Number == pullRequest.Number {
+			remote.pulls[number][i] = pullRequest
+			return
+		}
+	}
+
+	remote.pulls[number] = append(remote.pulls[number], pullRequest)
+}
+
+// SetRateLimitedUntil makes every call fail with a tracker.RateLimitError until resetAt, a zero time lifts the limit.
+func (t *Tracker) SetRateLimitedUntil(resetAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rateLimitedUntil = resetAt
+}
+
+// Comments returns the comments posted on an issue in order.
+func (t *Tracker) Comments(repo tracker.Repository, number int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remote, ok := t.repos[repo]
+	if !ok {
+		return nil
+	}
+
+	return append([]string(nil), remote.comments[number]...)
+}
+
+// Issues returns a snapshot of every issue stored for repo ordered by number.
+func (t *Tracker) Issues(repo tracker.Repository) []tracker.Issue {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	remote, ok := t.repos[repo]
+	if !ok {
+		return nil
+	}
+
+	return remote.sortedIssues()
+}
+
+func (t *Tracker) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.mu.Unlock()
+
+	state := options.State
+	if state == "" {
+		state = "open"
+	}
+
+	var issues []tracker.Issue
+	for _, issue := range remote.sortedIssues() {
+		if state == "all" || issue.State == state {
+			issues = append(issues, issue)
+		}
+	}
+
+	return issues, nil
+}
+
+func (t *Tracker) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.mu.Unlock()
+
+	issue, ok := remote.issues[number]
+	if !ok {
+		return nil, tracker.ErrNotFound
+	}
+
+	return copyIssue(issue), nil
+}
+
+func (t *Tracker) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	issue := &tracker.Issue{
+		Number:    remote.nextNumber,
+		State:     "open",
+		HTMLURL:   "https://fake.tracker/" + repo.String() + "/issues/" + strconv.Itoa(remote.nextNumber),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	remote.nextNumber++
+	applyRequest(issue, request, now)
+	remote.issues[issue.Number] = issue
+
+	return copyIssue(issue), nil
+}
+
+func (t *Tracker) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.mu.Unlock()
+
+	issue, ok := remote.issues[number]
+	if !ok {
+		return nil, tracker.ErrNotFound
+	}
+
+	applyRequest(issue, request, time.Now().UTC())
+
+	return copyIssue(issue), nil
+}
+
+func (t *Tracker) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	return t.UpdateIssue(ctx, repo, number, tracker.IssueRequest{State: tracker.StringPtr("closed")})
+}
+
+func (t *Tracker) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	defer t.mu.Unlock()
+
+	if _, ok := remote.issues[number]; !ok {
+		return nil, tracker.ErrNotFound
+	}
+
+	return append([]tracker.PullRequest{}, remote.pulls[number]...), nil
+}
+
+func (t *Tracker) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	defer t.mu.Unlock()
+
+	if _, ok := remote.issues[number]; !ok {
+		return tracker.ErrNotFound
+	}
+
+	remote.comments[number] = append(remote.comments[number], body)
+	return nil
+}
+
+func (t *Tracker) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	remote, err := t.repository(ctx, repo)
+	if err != nil {
+		return err
+	}
+	defer t.mu.Unlock()
+
+	issue, ok := remote.issues[number]
+	if !ok {
+		return tracker.ErrNotFound
+	}
+
+	issue.Locked = true
+	return nil
+}
+
+// repository authorizes the call and returns the stored repository with the tracker lock held.
+func (t *Tracker) repository(ctx context.Context, repo tracker.Repository) (*repository, error) {
+	if t.Token != nil && t.ValidToken != "" {
+		token, err := t.Token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if token != t.ValidToken {
+			return nil, tracker.ErrBadCredentials
+		}
+	}
+
+	t.mu.Lock()
+	if time.Now().Before(t.rateLimitedUntil) {
+		resetAt := t.rateLimitedUntil
+		t.mu.Unlock()
+		return nil, &tracker.RateLimitError{ResetAt: resetAt}
+	}
+
+	remote, ok := t.repos[repo]
+	if !ok {
+		t.mu.Unlock()
+		return nil, tracker.ErrNotFound
+	}
+
+	return remote, nil
+}
+
+func (r *repository) sortedIssues() []tracker.Issue {
+	issues := make([]tracker.Issue, 0, len(r.issues))
+	for _, issue := range r.issues {
+		issues = append(issues, *copyIssue(issue))
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number < issues[j].Number })
+	return issues
+}
+
+func applyRequest(issue *tracker.Issue, request tracker.IssueRequest, now time.Time) {
+	if request.Title != nil {
+		issue.Title = *request.Title
+	}
+
+	if request.Body != nil {
+		issue.Body = *request.Body
+	}
+
+	if request.Labels != nil {
+		issue.Labels = append([]string(nil), request.Labels...)
+	}
+
+	if request.Assignees != nil {
+		issue.Assignees = append([]string(nil), request.Assignees...)
+	}
+
+	if request.Milestone != nil {
+		issue.Milestone = *request.Milestone
+	}
+
+	if request.State != nil && *request.State != issue.State {
+		issue.State = *request.State
+		if issue.State == "closed" {
+			issue.ClosedAt = &now
+			issue.StateReason = "completed"
+		} else {
+			issue.ClosedAt = nil
+			issue.StateReason = ""
+		}
+	}
+
+	if request.StateReason != nil && issue.State == "closed" {
+		issue.StateReason = *request.StateReason
+	}
+
+	issue.UpdatedAt = now
+}
+
+func copyIssue(issue *tracker.Issue) *tracker.Issue {
+	out := *issue
+	out.Labels = append([]string(nil), issue.Labels...)
+	out.Assignees = append([]string(nil), issue.Assignees...)
+
+	if issue.ClosedAt != nil {
+		closedAt := *issue.ClosedAt
+		out.ClosedAt = &closedAt
+	}
+
+	return &out
+}
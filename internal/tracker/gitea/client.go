@@ -0,0 +1,359 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// defaultPageSize is used when Options.PageSize is not set, gitea caps pages at its MAX_RESPONSE_ITEMS (50 by default).
+const defaultPageSize = 50
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL of the gitea or forgejo API (e.g. https://gitea.example.com/api/v1).
+	BaseURL string
+	// PageSize is the number of items requested per page when listing.
+	PageSize int
+	// Token is an access token authenticating every request.
+	Token tracker.TokenSource
+	// Transport sends the requests (e.g. a metrics.Transport), defaults to the resty transport.
+	Transport http.RoundTripper
+}
+
+// Client is the gitea REST API implementation of tracker.IssueTracker, forgejo serves the same API.
+type Client struct {
+	baseURL  string
+	pageSize int
+	token    tracker.TokenSource
+	resty    *resty.Client
+}
+
+var _ tracker.IssueTracker = &Client{}
+
+func NewClient(options Options) *Client {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &Client{
+		baseURL:  strings.TrimSuffix(options.BaseURL, "/"),
+		pageSize: pageSize,
+		token:    options.Token,
+		resty:    resty.NewWithClient(&http.Client{Transport: options.Transport}),
+	}
+}
+
+// ListIssues follows the Link header through every page, pull requests are filtered out by gitea itself.
+func (c *Client) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	state := options.State
+	if state == "" {
+		state = "open"
+	}
+
+	giteaIssues, err := listAll[Issue](ctx, c, fmt.Sprintf("%s?type=issues&state=%s&limit=%d", c.repoURL(repo, "/issues"), url.QueryEscape(state), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []tracker.Issue
+	for i := range giteaIssues {
+		issues = append(issues, *giteaIssues[i].toTracker())
+	}
+
+	return issues, nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	giteaIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetResult(giteaIssue).Get(c.repoURL(repo, fmt.Sprintf("/issues/%d", number)))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return giteaIssue.toTracker(), nil
+}
+
+func (c *Client) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	giteaIssue := &Issue{}
+
+	body, err := c.newIssueRequest(ctx, repo, request)
+	if err != nil {
+		return nil, err
+	}
+
+	body.Labels, err = c.resolveLabels(ctx, repo, request.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(body).SetResult(giteaIssue).Post(c.repoURL(repo, "/issues"))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return giteaIssue.toTracker(), nil
+}
+
+// UpdateIssue replaces the labels through the issue labels endpoint first, the edit endpoint ignores them.
+func (c *Client) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	giteaIssue := &Issue{}
+
+	body, err := c.newIssueRequest(ctx, repo, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Labels) != 0 {
+		labelIDs, err := c.resolveLabels(ctx, repo, request.Labels)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := req.SetBody(LabelsRequest{Labels: labelIDs}).Put(c.repoURL(repo, fmt.Sprintf("/issues/%d/labels", number)))
+		if err = checkResponse(res, err); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(body).SetResult(giteaIssue).Patch(c.repoURL(repo, fmt.Sprintf("/issues/%d", number)))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return giteaIssue.toTracker(), nil
+}
+
+func (c *Client) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	return c.UpdateIssue(ctx, repo, number, tracker.IssueRequest{State: tracker.StringPtr("closed")})
+}
+
+// ListLinkedPullRequests walks the issue timeline for references coming from pull requests.
+func (c *Client) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	timeline, err := listAll[TimelineComment](ctx, c, fmt.Sprintf("%s?limit=%d", c.repoURL(repo, fmt.Sprintf("/issues/%d/timeline", number)), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var pullRequests []tracker.PullRequest
+	seen := map[string]bool{}
+	for i := range timeline {
+		pullRequest := timeline[i].linkedPullRequest(repo)
+		if pullRequest == nil {
+			continue
+		}
+
+		key := fmt.Sprintf("%s#%d", pullRequest.Repository, pullRequest.Number)
+		if !seen[key] {
+			seen[key] = true
+			pullRequests = append(pullRequests, *pullRequest)
+		}
+	}
+
+	return pullRequests, nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetBody(CommentRequest{Body: body}).Post(c.repoURL(repo, fmt.Sprintf("/issues/%d/comments", number)))
+	return checkResponse(res, err)
+}
+
+// LockIssue is not offered by the gitea API.
+func (c *Client) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	return fmt.Errorf("locking gitea issues: %w", tracker.ErrUnsupported)
+}
+
+// newIssueRequest converts a tracker request, gitea has no close reason so the state reason is dropped.
+func (c *Client) newIssueRequest(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (IssueRequest, error) {
+	body := IssueRequest{
+		Title:     request.Title,
+		Body:      request.Body,
+		State:     request.State,
+		Assignees: request.Assignees,
+	}
+
+	if request.Milestone != nil {
+		milestoneID, err := c.resolveMilestone(ctx, repo, *request.Milestone)
+		if err != nil {
+			return IssueRequest{}, err
+		}
+
+		body.Milestone = &milestoneID
+	}
+
+	return body, nil
+}
+
+// resolveLabels returns the ids of the repo labels with the given names.
+func (c *Client) resolveLabels(ctx context.Context, repo tracker.Repository, names []string) ([]int64, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	labels, err := listAll[Label](ctx, c, fmt.Sprintf("%s?limit=%d", c.repoURL(repo, "/labels"), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	labelIDs := make([]int64, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, label := range labels {
+			if label.Name == name {
+				labelIDs = append(labelIDs, label.ID)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("label %q does not exist in %s", name, repo)
+		}
+	}
+
+	return labelIDs, nil
+}
+
+// resolveMilestone returns the id of the milestone with the given title.
+func (c *Client) resolveMilestone(ctx context.Context, repo tracker.Repository, title string) (int64, error) {
+	milestones, err := listAll[Milestone](ctx, c, fmt.Sprintf("%s?state=all&limit=%d", c.repoURL(repo, "/milestones"), c.pageSize))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.Title == title {
+			return milestone.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("milestone %q does not exist in %s", title, repo)
+}
+
+// listAll requests firstURL and every page after it, following the rel="next" Link header.
+func listAll[T any](ctx context.Context, c *Client, firstURL string) ([]T, error) {
+	var items []T
+
+	for nextURL := firstURL; nextURL != ""; {
+		var page []T
+
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := req.SetResult(&page).Get(nextURL)
+		if err = checkResponse(res, err); err != nil {
+			return nil, err
+		}
+
+		items = append(items, page...)
+		nextURL = nextPageURL(res.Header().Get("Link"))
+	}
+
+	return items, nil
+}
+
+func (c *Client) newRequest(ctx context.Context) (*resty.Request, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.resty.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetHeader("Authorization", fmt.Sprintf("token %s", token)).
+		SetError(&ErrorResponse{}).
+		ForceContentType("application/json"), nil
+}
+
+func (c *Client) repoURL(repo tracker.Repository, path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s%s", c.baseURL, repo.Owner, repo.Name, path)
+}
+
+// nextPageURL extracts the rel="next" target of a Link header, or returns "" on the last page.
+func nextPageURL(linkHeader string) string {
+	for _, link := range strings.Split(linkHeader, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+
+	return ""
+}
+
+// checkResponse folds transport errors and non 2xx statuses into a single error.
+func checkResponse(res *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode() {
+	case http.StatusUnauthorized:
+		return tracker.ErrBadCredentials
+	case http.StatusNotFound:
+		return tracker.ErrNotFound
+	case http.StatusTooManyRequests:
+		// gitea has no rate limiter of its own, a 429 comes from a proxy in front of it
+		resetAt := time.Now().Add(time.Minute)
+		if seconds, err := strconv.Atoi(res.Header().Get("Retry-After")); err == nil {
+			resetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+
+		return &tracker.RateLimitError{ResetAt: resetAt}
+	}
+
+	if res.IsError() {
+		message := res.Status()
+		if errorResponse, ok := res.Error().(*ErrorResponse); ok && errorResponse.Message != "" {
+			message = errorResponse.Message
+		}
+
+		return fmt.Errorf("gitea %s %s failed with status %d: %s", res.Request.Method, res.Request.URL, res.StatusCode(), message)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package gitea_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/gitea"
+)
+
+var _ = Describe("Gitea Client", func() {
+	var server *httptest.Server
+	var mux *http.ServeMux
+	var client *gitea.Client
+	repo := tracker.Repository{Host: "gitea.example.com", Owner: "owner", Name: "repo"}
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		client = gitea.NewClient(gitea.Options{
+			BaseURL:  server.URL + "/api/v1",
+			PageSize: 2,
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+		mux.HandleFunc("/api/v1/repos/owner/repo/labels", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[{"id": 11, "name": "bug"}, {"id": 12, "name": "p1"}]`))
+		})
+		mux.HandleFunc("/api/v1/repos/owner/repo/milestones", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("state")).To(Equal("all"))
+
+			_, _ = w.Write([]byte(`[{"id": 4, "title": "v1"}]`))
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should send the token and resolve labels and the milestone on create", func() {
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.Header.Get("Authorization")).To(Equal("token token"))
+
+			body := gitea.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.Title).To(Equal("title"))
+			Expect(body.Labels).To(Equal([]int64{12, 11}))
+			Expect(*body.Milestone).To(Equal(int64(4)))
+			Expect(body.Assignees).To(Equal([]string{"octocat"}))
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": 7, "title": "title", "state": "open", "html_url": "https://gitea.example.com/owner/repo/issues/7",
+				"labels": [{"id": 11, "name": "bug"}, {"id": 12, "name": "p1"}], "assignees": [{"login": "octocat"}], "milestone": {"id": 4, "title": "v1"}}`))
+		})
+
+		issue, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{
+			Title:     tracker.StringPtr("title"),
+			Labels:    []string{"p1", "bug"},
+			Assignees: []string{"octocat"},
+			Milestone: tracker.StringPtr("v1"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.Number).To(Equal(7))
+		Expect(issue.Labels).To(Equal([]string{"bug", "p1"}))
+		Expect(issue.Assignees).To(Equal([]string{"octocat"}))
+		Expect(issue.Milestone).To(Equal("v1"))
+
+		_, err = client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("title"), Labels: []string{"missing"}})
+		Expect(err).To(MatchError(ContainSubstring(`label "missing" does not exist`)))
+	})
+
+	It("should follow pagination when listing issues", func() {
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("type")).To(Equal("issues"))
+			Expect(r.URL.Query().Get("limit")).To(Equal("2"))
+
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/api/v1/repos/owner/repo/issues?type=issues&state=all&limit=2&page=2>; rel="next"`, server.URL))
+				_, _ = w.Write([]byte(`[{"number": 1, "state": "open"}, {"number": 2, "state": "closed"}]`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`[{"number": 3, "state": "open"}]`))
+		})
+
+		issues, err := client.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(3))
+	})
+
+	It("should replace the labels before editing the issue", func() {
+		var calls []string
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues/3/labels", func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, r.Method+" labels")
+			Expect(r.Method).To(Equal(http.MethodPut))
+
+			body := gitea.LabelsRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body.Labels).To(Equal([]int64{11}))
+
+			_, _ = w.Write([]byte(`[{"id": 11, "name": "bug"}]`))
+		})
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues/3", func(w http.ResponseWriter, r *http.Request) {
+			calls = append(calls, r.Method+" issue")
+			Expect(r.Method).To(Equal(http.MethodPatch))
+
+			body := gitea.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.State).To(Equal("closed"))
+			Expect(body.Labels).To(BeEmpty())
+
+			_, _ = w.Write([]byte(`{"number": 3, "state": "closed", "labels": [{"id": 11, "name": "bug"}]}`))
+		})
+
+		issue, err := client.UpdateIssue(ctx, repo, 3, tracker.IssueRequest{State: tracker.StringPtr("closed"), Labels: []string{"bug"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.State).To(Equal("closed"))
+		Expect(issue.Labels).To(Equal([]string{"bug"}))
+		Expect(calls).To(Equal([]string{"PUT labels", "PATCH issue"}))
+	})
+
+	It("should find pull requests referencing the issue in the timeline", func() {
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues/4/timeline", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[
+				{"type": "comment"},
+				{"type": "issue_ref", "ref_issue": {"number": 9, "state": "open"}},
+				{"type": "pull_ref", "ref_issue": {"number": 10, "state": "open", "html_url": "https://gitea.example.com/owner/repo/pulls/10", "pull_request": {"merged": false}}},
+				{"type": "comment_ref", "ref_issue": {"number": 10, "state": "open", "pull_request": {"merged": false}}},
+				{"type": "pull_ref", "ref_issue": {"number": 11, "state": "closed", "html_url": "https://gitea.example.com/other/fork/pulls/11", "pull_request": {"merged": true}, "repository": {"name": "fork", "owner": {"login": "other"}}}}
+			]`))
+		})
+
+		pullRequests, err := client.ListLinkedPullRequests(ctx, repo, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pullRequests).To(Equal([]tracker.PullRequest{
+			{Repository: repo, Number: 10, HTMLURL: "https://gitea.example.com/owner/repo/pulls/10", State: "open"},
+			{Repository: tracker.Repository{Host: "gitea.example.com", Owner: "other", Name: "fork"}, Number: 11, HTMLURL: "https://gitea.example.com/other/fork/pulls/11", State: "closed", Merged: true},
+		}))
+	})
+
+	It("should map errors and refuse to lock", func() {
+		mux.HandleFunc("/api/v1/repos/owner/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "token is required"}`))
+		})
+
+		_, err := client.GetIssue(ctx, repo, 1)
+		Expect(err).To(MatchError(tracker.ErrBadCredentials))
+
+		_, err = client.GetIssue(ctx, repo, 2)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+
+		Expect(client.LockIssue(ctx, repo, 1)).To(MatchError(tracker.ErrUnsupported))
+	})
+})
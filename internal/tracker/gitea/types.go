@@ -0,0 +1,137 @@
+package gitea
+
+import (
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// User is the subset of the gitea user object shared by every payload that embeds one.
+type User struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+}
+
+type Label struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Milestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    User   `json:"owner"`
+}
+
+// PullRequestMeta is set on the issues that are pull requests.
+type PullRequestMeta struct {
+	Merged   bool       `json:"merged"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// Issue mirrors the response of the gitea issues API.
+type Issue struct {
+	ID          int64            `json:"id"`
+	Number      int              `json:"number"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	State       string           `json:"state"`
+	IsLocked    bool             `json:"is_locked"`
+	HTMLURL     string           `json:"html_url"`
+	Labels      []Label          `json:"labels"`
+	Assignees   []User           `json:"assignees"`
+	Milestone   *Milestone       `json:"milestone"`
+	PullRequest *PullRequestMeta `json:"pull_request"`
+	Repository  *Repository      `json:"repository"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	ClosedAt    *time.Time       `json:"closed_at"`
+}
+
+// IssueRequest is the body of the create and edit issue endpoints, labels are only taken on create.
+type IssueRequest struct {
+	Title     *string  `json:"title,omitempty"`
+	Body      *string  `json:"body,omitempty"`
+	State     *string  `json:"state,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+	Milestone *int64   `json:"milestone,omitempty"`
+}
+
+// LabelsRequest is the body of the replace issue labels endpoint.
+type LabelsRequest struct {
+	Labels []int64 `json:"labels"`
+}
+
+// CommentRequest is the body of the create issue comment endpoint.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// TimelineComment mirrors a single entry of the issue timeline, only the fields needed to find linked pull requests are kept.
+type TimelineComment struct {
+	Type      string    `json:"type"`
+	RefIssue  *Issue    `json:"ref_issue"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrorResponse is the body gitea returns alongside a non 2xx status.
+type ErrorResponse struct {
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+func (i *Issue) toTracker() *tracker.Issue {
+	issue := &tracker.Issue{
+		Number:    i.Number,
+		Title:     i.Title,
+		Body:      i.Body,
+		State:     i.State,
+		Locked:    i.IsLocked,
+		HTMLURL:   i.HTMLURL,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		ClosedAt:  i.ClosedAt,
+	}
+
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.Login)
+	}
+
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+
+	return issue
+}
+
+// linkedPullRequest returns the pull request the timeline entry references, nil when it references a plain issue.
+func (c *TimelineComment) linkedPullRequest(repo tracker.Repository) *tracker.PullRequest {
+	if c.RefIssue == nil || c.RefIssue.PullRequest == nil {
+		return nil
+	}
+
+	source := c.RefIssue
+	if source.Repository != nil {
+		repo = tracker.Repository{Host: repo.Host, Owner: source.Repository.Owner.Login, Name: source.Repository.Name}
+	}
+
+	return &tracker.PullRequest{
+		Repository: repo,
+		Number:     source.Number,
+		HTMLURL:    source.HTMLURL,
+		State:      source.State,
+		Merged:     source.PullRequest.Merged,
+		LinkedAt:   c.CreatedAt,
+	}
+}
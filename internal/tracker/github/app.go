@@ -0,0 +1,176 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	// appJWTLifetime stays under the 10 minutes github accepts, iat is backdated to absorb clock drift.
+	appJWTLifetime = 9 * time.Minute
+	appJWTBackdate = time.Minute
+	// installationTokenRefreshWindow renews an installation token this long before github expires it.
+	installationTokenRefreshWindow = 5 * time.Minute
+)
+
+// AppCredentials identify a github App installation, PrivateKey is the PEM key downloaded from the App settings.
+type AppCredentials struct {
+	AppID          string
+	InstallationID string
+	PrivateKey     []byte
+}
+
+// installationTokenResponse is the body of the create installation access token endpoint.
+type installationTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// installationToken is the cached token of an installation, its lock is held during the exchange
+// so concurrent reconciles of the same installation wait for a single exchange without blocking other installations.
+type installationToken struct {
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// AppTokenSource exchanges App credentials for installation access tokens and caches them until shortly before they expire.
+type AppTokenSource struct {
+	baseURL string
+	resty   *resty.Client
+	now     func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*installationToken
+}
+
+// NewAppTokenSource sends the token exchanges through transport (e.g. the metrics and tracing transports of the host),
+// nil uses the resty transport.
+func NewAppTokenSource(baseURL string, transport http.RoundTripper) *AppTokenSource {
+	return &AppTokenSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		resty:   resty.NewWithClient(&http.Client{Transport: transport}),
+		now:     time.Now,
+		tokens:  map[string]*installationToken{},
+	}
+}
+
+// InstallationToken returns a cached installation token, or signs a new App JWT and asks github for one.
+func (s *AppTokenSource) InstallationToken(ctx context.Context, credentials AppCredentials) (string, error) {
+	key := credentials.cacheKey()
+
+	s.mu.Lock()
+	cached, ok := s.tokens[key]
+	if !ok {
+		cached = &installationToken{}
+		s.tokens[key] = cached
+	}
+	s.mu.Unlock()
+
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+
+	if cached.token != "" && s.now().Add(installationTokenRefreshWindow).Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+
+	privateKey, err := ParsePrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	jwt, err := SignAppJWT(credentials.AppID, privateKey, s.now())
+	if err != nil {
+		return "", err
+	}
+
+	response := &installationTokenResponse{}
+	res, err := s.resty.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", jwt)).
+		SetError(&ErrorResponse{}).
+		ForceContentType("application/json").
+		SetResult(response).
+		Post(fmt.Sprintf("%s/app/installations/%s/access_tokens", s.baseURL, credentials.InstallationID))
+	if err = checkResponse(res, err); err != nil {
+		return "", fmt.Errorf("could not create installation token for app %s: %w", credentials.AppID, err)
+	}
+
+	cached.token, cached.expiresAt = response.Token, response.ExpiresAt
+
+	return response.Token, nil
+}
+
+// cacheKey includes a digest of the private key so a rotated key never reuses a token minted with the old one.
+func (c AppCredentials) cacheKey() string {
+	sum := sha256.Sum256(c.PrivateKey)
+
+	return fmt.Sprintf("%s/%s/%s", c.AppID, c.InstallationID, hex.EncodeToString(sum[:8]))
+}
+
+// SignAppJWT creates the RS256 JWT authenticating as the App itself.
+func SignAppJWT(appID string, privateKey *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-appJWTBackdate).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": appID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(nil, privateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", signingInput, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
+
+// ParsePrivateKey reads a PEM encoded RSA key, github hands out PKCS#1 keys but PKCS#8 is accepted too.
+func ParsePrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return rsaKey, nil
+}
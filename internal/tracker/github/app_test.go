@@ -0,0 +1,124 @@
+package github_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/github"
+)
+
+var _ = Describe("Github App Token Source", func() {
+	var server *httptest.Server
+	var privateKey *rsa.PrivateKey
+	var credentials github.AppCredentials
+	var exchanges int
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		var err error
+		privateKey, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		credentials = github.AppCredentials{
+			AppID:          "1234",
+			InstallationID: "5678",
+			PrivateKey:     pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)}),
+		}
+
+		exchanges = 0
+		mux := http.NewServeMux()
+		mux.HandleFunc("/app/installations/5678/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			exchanges++
+
+			jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			parts := strings.Split(jwt, ".")
+			Expect(parts).To(HaveLen(3))
+
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			Expect(err).NotTo(HaveOccurred())
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			Expect(rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, digest[:], signature)).To(Succeed())
+
+			claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+			Expect(err).NotTo(HaveOccurred())
+			claims := map[string]any{}
+			Expect(json.Unmarshal(claimsJSON, &claims)).To(Succeed())
+			Expect(claims["iss"]).To(Equal("1234"))
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"token": "installation-token-%d", "expires_at": %q}`, exchanges, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		})
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should exchange a signed JWT for an installation token and cache it", func() {
+		tokenSource := github.NewAppTokenSource(server.URL, nil)
+
+		token, err := tokenSource.InstallationToken(ctx, credentials)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("installation-token-1"))
+
+		token, err = tokenSource.InstallationToken(ctx, credentials)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token).To(Equal("installation-token-1"))
+		Expect(exchanges).To(Equal(1))
+	})
+
+	It("should not hold other installations back while an exchange hangs", func() {
+		release := make(chan struct{})
+		slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/app/installations/slow/access_tokens" {
+				<-release
+			}
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = fmt.Fprintf(w, `{"token": "token-for-%s", "expires_at": %q}`, r.URL.Path, time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		}))
+		defer slowServer.Close()
+		defer close(release)
+
+		tokenSource := github.NewAppTokenSource(slowServer.URL, nil)
+		slowCredentials := credentials
+		slowCredentials.InstallationID = "slow"
+		go func() {
+			defer GinkgoRecover()
+			_, _ = tokenSource.InstallationToken(ctx, slowCredentials)
+		}()
+
+		done := make(chan error)
+		go func() {
+			_, err := tokenSource.InstallationToken(ctx, credentials)
+			done <- err
+		}()
+
+		Eventually(done).WithTimeout(5 * time.Second).Should(Receive(BeNil()))
+	})
+
+	It("should reject keys that are not PEM encoded", func() {
+		credentials.PrivateKey = []byte("not a key")
+
+		_, err := github.NewAppTokenSource(server.URL, nil).InstallationToken(ctx, credentials)
+		Expect(err).To(HaveOccurred())
+		Expect(exchanges).To(Equal(0))
+	})
+})
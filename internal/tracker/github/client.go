@@ -0,0 +1,397 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// defaultPageSize is used when Options.PageSize is not set, 100 is the maximum github allows.
+const defaultPageSize = 100
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL of the github API (e.g. https://api.github.com).
+	BaseURL string
+	// Host is the configured host the rate limit gauge is recorded under, defaults to the host of BaseURL.
+	Host string
+	// PageSize is the number of items requested per page when listing.
+	PageSize int
+	// Token authenticates every request.
+	Token tracker.TokenSource
+	// Transport sends the requests (e.g. a metrics.Transport), defaults to the resty transport.
+	Transport http.RoundTripper
+	// ETagCacheSize caps the responses kept for conditional requests, the least recently used go first. Defaults to 1000.
+	ETagCacheSize int
+	// ETagTTL is how long a stored response is replayed on a 304, zero keeps it until it is evicted.
+	ETagTTL time.Duration
+}
+
+// Client is the github REST API implementation of tracker.IssueTracker.
+type Client struct {
+	baseURL  string
+	host     string
+	pageSize int
+	token    tracker.TokenSource
+	resty    *resty.Client
+
+	// etags holds the last response of the recent GETs, keyed by URL, so it can be replayed on a 304.
+	etags *etagCache
+
+	rateLimitsMu sync.Mutex
+	// rateLimits is the last known core quota of every token, keyed by tokenFingerprint, until its window resets.
+	rateLimits map[string]rateLimit
+}
+
+var _ tracker.IssueTracker = &Client{}
+
+func NewClient(options Options) *Client {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	host := options.Host
+	if host == "" {
+		if baseURL, err := url.Parse(options.BaseURL); err == nil {
+			host = baseURL.Host
+		}
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(options.BaseURL, "/"),
+		host:       host,
+		pageSize:   pageSize,
+		token:      options.Token,
+		resty:      resty.NewWithClient(&http.Client{Transport: options.Transport}),
+		etags:      newETagCache(options.ETagCacheSize, options.ETagTTL),
+		rateLimits: map[string]rateLimit{},
+	}
+	c.resty.OnAfterResponse(func(_ *resty.Client, res *resty.Response) error {
+		c.recordRateLimit(res)
+		return nil
+	})
+
+	return c
+}
+
+// ListIssues follows the Link header through every page, pull requests returned by the issues endpoint are dropped.
+func (c *Client) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	state := options.State
+	if state == "" {
+		state = "open"
+	}
+
+	githubIssues, err := listAll[Issue](ctx, c, fmt.Sprintf("%s?state=%s&per_page=%d", c.repoURL(repo, "/issues"), url.QueryEscape(state), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []tracker.Issue
+	for i := range githubIssues {
+		if githubIssues[i].PullRequest != nil {
+			continue
+		}
+
+		issues = append(issues, *githubIssues[i].toTracker())
+	}
+
+	return issues, nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	githubIssue := &Issue{}
+
+	_, err := c.get(ctx, c.repoURL(repo, fmt.Sprintf("/issues/%d", number)), githubIssue)
+	if err != nil {
+		return nil, err
+	}
+
+	return githubIssue.toTracker(), nil
+}
+
+func (c *Client) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	githubIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	milestoneNumber, err := c.resolveMilestone(ctx, repo, request.Milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(newIssueRequest(request, milestoneNumber)).SetResult(githubIssue).Post(c.repoURL(repo, "/issues"))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return githubIssue.toTracker(), nil
+}
+
+func (c *Client) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	githubIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	milestoneNumber, err := c.resolveMilestone(ctx, repo, request.Milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	issueURL := c.repoURL(repo, fmt.Sprintf("/issues/%d", number))
+	res, err := req.SetBody(newIssueRequest(request, milestoneNumber)).SetResult(githubIssue).Patch(issueURL)
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	// A closed issue is rarely read again, its stored responses would only take memory
+	if githubIssue.State == "closed" {
+		c.etags.forget(issueURL)
+	}
+
+	return githubIssue.toTracker(), nil
+}
+
+func (c *Client) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	return c.UpdateIssue(ctx, repo, number, tracker.IssueRequest{State: tracker.StringPtr("closed")})
+}
+
+// closingPullRequestsQuery lists the pull requests that close the issue when merged, which covers the ones linked
+// through the development sidebar: the timeline API reports those as connected events without the pull request.
+const closingPullRequestsQuery = `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) {
+    issue(number: $number) {
+      closedByPullRequestsReferences(first: 100, includeClosedPrs: true) {
+        nodes { number url state merged createdAt repository { name owner { login } } }
+      }
+    }
+  }
+}`
+
+// ListLinkedPullRequests collects the pull requests cross-referencing the issue from its timeline
+// and the ones closing it (closing keywords and development sidebar links) through graphql.
+func (c *Client) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	timeline, err := listAll[TimelineEvent](ctx, c, fmt.Sprintf("%s?per_page=%d", c.repoURL(repo, fmt.Sprintf("/issues/%d/timeline", number)), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	closing, err := c.listClosingPullRequests(ctx, repo, number)
+	if err != nil {
+		return nil, err
+	}
+
+	var pullRequests []tracker.PullRequest
+	seen := map[string]bool{}
+	add := func(pullRequest tracker.PullRequest) {
+		key := fmt.Sprintf("%s#%d", pullRequest.Repository, pullRequest.Number)
+		if !seen[key] {
+			seen[key] = true
+			pullRequests = append(pullRequests, pullRequest)
+		}
+	}
+
+	for i := range timeline {
+		if timeline[i].Event != "cross-referenced" {
+			continue
+		}
+
+		if pullRequest := timeline[i].linkedPullRequest(repo); pullRequest != nil {
+			add(*pullRequest)
+		}
+	}
+
+	for _, pullRequest := range closing {
+		add(pullRequest)
+	}
+
+	return pullRequests, nil
+}
+
+// listClosingPullRequests runs closingPullRequestsQuery, only the first 100 pull requests are returned.
+func (c *Client) listClosingPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ClosingPullRequestsResponse{}
+	res, err := req.SetBody(GraphQLRequest{
+		Query:     closingPullRequestsQuery,
+		Variables: map[string]any{"owner": repo.Owner, "name": repo.Name, "number": number},
+	}).SetResult(response).Post(c.graphqlURL())
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	if len(response.Errors) > 0 {
+		if response.Errors[0].Type == "NOT_FOUND" {
+			return nil, tracker.ErrNotFound
+		}
+
+		return nil, fmt.Errorf("github graphql query for %s#%d failed: %s", repo, number, response.Errors[0].Message)
+	}
+
+	if response.Data.Repository == nil || response.Data.Repository.Issue == nil {
+		return nil, tracker.ErrNotFound
+	}
+
+	var pullRequests []tracker.PullRequest
+	for _, pullRequest := range response.Data.Repository.Issue.ClosedByPullRequestsReferences.Nodes {
+		pullRequests = append(pullRequests, pullRequest.toTracker())
+	}
+
+	return pullRequests, nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetBody(CommentRequest{Body: body}).Post(c.repoURL(repo, fmt.Sprintf("/issues/%d/comments", number)))
+	return checkResponse(res, err)
+}
+
+// LockIssue locks the conversation of an issue with the resolved reason.
+func (c *Client) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetBody(LockRequest{LockReason: "resolved"}).Put(c.repoURL(repo, fmt.Sprintf("/issues/%d/lock", number)))
+	return checkResponse(res, err)
+}
+
+// resolveMilestone returns the number of the milestone with the given title, nil titles resolve to nil.
+func (c *Client) resolveMilestone(ctx context.Context, repo tracker.Repository, title *string) (*int, error) {
+	if title == nil {
+		return nil, nil
+	}
+
+	milestones, err := listAll[Milestone](ctx, c, fmt.Sprintf("%s?state=all&per_page=%d", c.repoURL(repo, "/milestones"), c.pageSize))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.Title == *title {
+			return &milestone.Number, nil
+		}
+	}
+
+	return nil, fmt.Errorf("milestone %q does not exist in %s", *title, repo)
+}
+
+// listAll requests firstURL and every page after it, following the rel="next" Link header.
+func listAll[T any](ctx context.Context, c *Client, firstURL string) ([]T, error) {
+	var items []T
+
+	for nextURL := firstURL; nextURL != ""; {
+		var page []T
+
+		link, err := c.get(ctx, nextURL, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page...)
+		nextURL = nextPageURL(link)
+	}
+
+	return items, nil
+}
+
+func (c *Client) newRequest(ctx context.Context) (*resty.Request, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkRateLimit(token); err != nil {
+		return nil, err
+	}
+
+	return c.resty.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/vnd.github+json").
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
+		SetError(&ErrorResponse{}).
+		ForceContentType("application/json"), nil
+}
+
+func (c *Client) repoURL(repo tracker.Repository, path string) string {
+	return fmt.Sprintf("%s/repos/%s/%s%s", c.baseURL, repo.Owner, repo.Name, path)
+}
+
+// graphqlURL is the graphql endpoint next to the REST API, /api/graphql on GitHub Enterprise Server.
+func (c *Client) graphqlURL() string {
+	if strings.HasSuffix(c.baseURL, "/api/v3") {
+		return strings.TrimSuffix(c.baseURL, "/v3") + "/graphql"
+	}
+
+	return c.baseURL + "/graphql"
+}
+
+// nextPageURL extracts the rel="next" target of a github Link header, or returns "" on the last page.
+func nextPageURL(linkHeader string) string {
+	for _, link := range strings.Split(linkHeader, ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+			}
+		}
+	}
+
+	return ""
+}
+
+// checkResponse folds transport errors and non 2xx statuses into a single error.
+func checkResponse(res *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode() {
+	case http.StatusUnauthorized:
+		return tracker.ErrBadCredentials
+	case http.StatusNotFound:
+		return tracker.ErrNotFound
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if rateLimitErr := rateLimitError(res); rateLimitErr != nil {
+			return rateLimitErr
+		}
+	}
+
+	if res.IsError() {
+		message := res.Status()
+		if errorResponse, ok := res.Error().(*ErrorResponse); ok && errorResponse.Message != "" {
+			message = errorResponse.Message
+		}
+
+		return fmt.Errorf("github %s %s failed with status %d: %s", res.Request.Method, res.Request.URL, res.StatusCode(), message)
+	}
+
+	return nil
+}
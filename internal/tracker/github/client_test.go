@@ -0,0 +1,404 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/github"
+)
+
+var _ = Describe("Github Client", func() {
+	var server *httptest.Server
+	var mux *http.ServeMux
+	var client *github.Client
+	repo := tracker.Repository{Owner: "owner", Name: "repo"}
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		mux = http.NewServeMux()
+		server = httptest.NewServer(mux)
+		client = github.NewClient(github.Options{
+			BaseURL:  server.URL,
+			PageSize: 2,
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should send the token and decode the created issue", func() {
+		mux.HandleFunc("/repos/owner/repo/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer token"))
+
+			body := github.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.Title).To(Equal("title"))
+			Expect(body.State).To(BeNil())
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": 7, "title": "title", "body": "body", "state": "open", "html_url": "https://github.com/owner/repo/issues/7", "labels": [{"name": "bug"}], "assignees": [{"login": "octocat"}]}`))
+		})
+
+		issue, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("title"), Body: tracker.StringPtr("body")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.Number).To(Equal(7))
+		Expect(issue.HTMLURL).To(Equal("https://github.com/owner/repo/issues/7"))
+		Expect(issue.Labels).To(Equal([]string{"bug"}))
+		Expect(issue.Assignees).To(Equal([]string{"octocat"}))
+	})
+
+	It("should route enterprise repos to the /api/v3 endpoint of their host", func() {
+		mux.HandleFunc("/api/v3/repos/owner/repo/issues/3", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodGet))
+
+			_, _ = w.Write([]byte(`{"number": 3, "title": "title", "state": "open", "html_url": "https://github.example.com/owner/repo/issues/3"}`))
+		})
+
+		hosts := tracker.Hosts{
+			"github.example.com": github.NewClient(github.Options{
+				BaseURL: server.URL + "/api/v3/",
+				Token: func(ctx context.Context) (string, error) {
+					return "token", nil
+				},
+			}),
+		}
+
+		issue, err := hosts.GetIssue(ctx, tracker.Repository{Host: "GitHub.example.com", Owner: "owner", Name: "repo"}, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.HTMLURL).To(Equal("https://github.example.com/owner/repo/issues/3"))
+
+		_, err = hosts.GetIssue(ctx, tracker.Repository{Host: "github.com", Owner: "owner", Name: "repo"}, 3)
+		Expect(err).To(MatchError(ContainSubstring(`no tracker is configured for host "github.com"`)))
+	})
+
+	It("should follow pagination and skip pull requests", func() {
+		mux.HandleFunc("/repos/owner/repo/issues", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("state")).To(Equal("all"))
+			Expect(r.URL.Query().Get("per_page")).To(Equal("2"))
+
+			if r.URL.Query().Get("page") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/issues?state=all&per_page=2&page=2>; rel="next", <%s/repos/owner/repo/issues?state=all&per_page=2&page=2>; rel="last"`, server.URL, server.URL))
+				_, _ = w.Write([]byte(`[{"number": 1, "state": "open"}, {"number": 2, "state": "open", "pull_request": {"url": "https://api.github.com/repos/owner/repo/pulls/2"}}]`))
+				return
+			}
+
+			_, _ = w.Write([]byte(`[{"number": 3, "state": "closed"}]`))
+		})
+
+		issues, err := client.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(2))
+		Expect(issues[0].Number).To(Equal(1))
+		Expect(issues[1].Number).To(Equal(3))
+	})
+
+	It("should resolve the milestone title to its number", func() {
+		mux.HandleFunc("/repos/owner/repo/milestones", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[{"number": 4, "title": "v1"}, {"number": 5, "title": "v2"}]`))
+		})
+		mux.HandleFunc("/repos/owner/repo/issues/3", func(w http.ResponseWriter, r *http.Request) {
+			body := github.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.Milestone).To(Equal(5))
+			Expect(body.Labels).To(Equal([]string{"bug"}))
+
+			_, _ = w.Write([]byte(`{"number": 3, "milestone": {"number": 5, "title": "v2"}, "labels": [{"name": "bug"}]}`))
+		})
+
+		issue, err := client.UpdateIssue(ctx, repo, 3, tracker.IssueRequest{Labels: []string{"bug"}, Milestone: tracker.StringPtr("v2")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.Milestone).To(Equal("v2"))
+
+		_, err = client.UpdateIssue(ctx, repo, 3, tracker.IssueRequest{Milestone: tracker.StringPtr("v3")})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should map 401 and 404 to the tracker errors", func() {
+		mux.HandleFunc("/repos/owner/repo/issues/1", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+		})
+
+		_, err := client.GetIssue(ctx, repo, 1)
+		Expect(err).To(MatchError(tracker.ErrBadCredentials))
+
+		_, err = client.GetIssue(ctx, repo, 2)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+	})
+
+	It("should close an issue with a PATCH", func() {
+		mux.HandleFunc("/repos/owner/repo/issues/3", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPatch))
+
+			body := github.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.State).To(Equal("closed"))
+
+			_, _ = w.Write([]byte(`{"number": 3, "state": "closed"}`))
+		})
+
+		issue, err := client.CloseIssue(ctx, repo, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.State).To(Equal("closed"))
+	})
+
+	It("should find linked pull requests in the timeline and the closing references", func() {
+		mux.HandleFunc("/repos/owner/repo/issues/4/timeline", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[
+				{"event": "labeled", "created_at": "2024-05-01T08:00:00Z"},
+				{"event": "cross-referenced", "created_at": "2024-05-01T09:00:00Z", "source": {"type": "issue", "issue": {"number": 9, "state": "open", "html_url": "https://github.com/owner/repo/issues/9", "repository": {"name": "repo", "owner": {"login": "owner"}}}}},
+				{"event": "cross-referenced", "created_at": "2024-05-01T09:30:00Z", "source": {"type": "issue", "issue": {"number": 10, "state": "open", "html_url": "https://github.com/owner/repo/pull/10", "pull_request": {"merged_at": null}, "repository": {"name": "repo", "owner": {"login": "owner"}}}}},
+				{"event": "connected", "created_at": "2024-05-01T10:00:00Z", "actor": {"login": "octocat"}},
+				{"event": "disconnected", "created_at": "2024-05-01T11:00:00Z", "actor": {"login": "octocat"}}
+			]`))
+		})
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal(http.MethodPost))
+
+			body := github.GraphQLRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(body.Query).To(ContainSubstring("closedByPullRequestsReferences"))
+			Expect(body.Variables).To(HaveKeyWithValue("number", BeEquivalentTo(4)))
+
+			_, _ = w.Write([]byte(`{"data": {"repository": {"issue": {"closedByPullRequestsReferences": {"nodes": [
+				{"number": 10, "url": "https://github.com/owner/repo/pull/10", "state": "OPEN", "merged": false, "createdAt": "2024-05-01T09:00:00Z", "repository": {"name": "repo", "owner": {"login": "owner"}}},
+				{"number": 11, "url": "https://github.com/other/fork/pull/11", "state": "MERGED", "merged": true, "createdAt": "2024-04-30T10:00:00Z", "repository": {"name": "fork", "owner": {"login": "other"}}}
+			]}}}}}`))
+		})
+
+		pullRequests, err := client.ListLinkedPullRequests(ctx, repo, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pullRequests).To(Equal([]tracker.PullRequest{
+			{Repository: repo, Number: 10, HTMLURL: "https://github.com/owner/repo/pull/10", State: "open", LinkedAt: time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)},
+			{Repository: tracker.Repository{Owner: "other", Name: "fork"}, Number: 11, HTMLURL: "https://github.com/other/fork/pull/11", State: "closed", Merged: true, LinkedAt: time.Date(2024, 4, 30, 10, 0, 0, 0, time.UTC)},
+		}))
+	})
+
+	It("should send graphql queries of enterprise hosts to /api/graphql", func() {
+		mux.HandleFunc("/api/v3/repos/owner/repo/issues/4/timeline", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[]`))
+		})
+		mux.HandleFunc("/api/graphql", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data": {"repository": null}, "errors": [{"type": "NOT_FOUND", "message": "Could not resolve to a Repository"}]}`))
+		})
+
+		enterpriseClient := github.NewClient(github.Options{
+			BaseURL: server.URL + "/api/v3",
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+
+		_, err := enterpriseClient.ListLinkedPullRequests(ctx, repo, 4)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+	})
+
+	It("should return a rate limit error once the quota is exhausted and stop calling github", func() {
+		resetAt := time.Now().Add(time.Hour).Truncate(time.Second)
+		calls := 0
+		mux.HandleFunc("/repos/owner/repo/issues/5", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", resetAt.Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+		})
+
+		var rateLimitErr *tracker.RateLimitError
+		_, err := client.GetIssue(ctx, repo, 5)
+		Expect(errors.As(err, &rateLimitErr)).To(BeTrue())
+		Expect(rateLimitErr.ResetAt).To(BeTemporally("==", resetAt))
+		Expect(rateLimitErr.Secondary).To(BeFalse())
+
+		_, err = client.GetIssue(ctx, repo, 5)
+		Expect(errors.As(err, &rateLimitErr)).To(BeTrue())
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should call github again once the rate limit window has reset", func() {
+		calls := 0
+		mux.HandleFunc("/repos/owner/repo/issues/5", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(-time.Second).Unix()))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+		})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.GetIssue(ctx, repo, 5)
+			Expect(err).To(HaveOccurred())
+		}
+
+		Expect(calls).To(Equal(2))
+	})
+
+	It("should recognize the secondary rate limit", func() {
+		mux.HandleFunc("/repos/owner/repo/issues/6", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "You have exceeded a secondary rate limit"}`))
+		})
+		mux.HandleFunc("/repos/owner/repo/issues/7", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message": "Resource not accessible by integration"}`))
+		})
+
+		var rateLimitErr *tracker.RateLimitError
+		_, err := client.GetIssue(ctx, repo, 6)
+		Expect(errors.As(err, &rateLimitErr)).To(BeTrue())
+		Expect(rateLimitErr.Secondary).To(BeTrue())
+		Expect(rateLimitErr.RetryAfter(time.Now())).To(BeNumerically("~", 30*time.Second, time.Second))
+
+		_, err = client.GetIssue(ctx, repo, 7)
+		Expect(err).To(HaveOccurred())
+		Expect(errors.As(err, &rateLimitErr)).To(BeFalse())
+	})
+
+	It("should replay the stored response on 304 Not Modified", func() {
+		calls := 0
+		mux.HandleFunc("/repos/owner/repo/issues/8", func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			_, _ = w.Write([]byte(`{"number": 8, "title": "cached"}`))
+		})
+
+		for i := 0; i < 2; i++ {
+			issue, err := client.GetIssue(ctx, repo, 8)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue.Title).To(Equal("cached"))
+		}
+
+		Expect(calls).To(Equal(2))
+		Expect(testutil.ToFloat64(metrics.RateLimitRemaining.WithLabelValues(strings.TrimPrefix(server.URL, "http://"), "core"))).To(Equal(4999.0))
+	})
+
+	It("should evict the least recently used and expired responses", func() {
+		conditional := map[string]bool{}
+		mux.HandleFunc("/repos/owner/repo/issues/", func(w http.ResponseWriter, r *http.Request) {
+			conditional[r.URL.Path] = r.Header.Get("If-None-Match") != ""
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = fmt.Fprintf(w, `{"number": 1, "title": %q}`, r.URL.Path)
+		})
+
+		client = github.NewClient(github.Options{
+			BaseURL:       server.URL,
+			ETagCacheSize: 2,
+			ETagTTL:       50 * time.Millisecond,
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+
+		for _, number := range []int{1, 2, 3} {
+			_, err := client.GetIssue(ctx, repo, number)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(client.ETagEntries()).To(Equal(2))
+
+		_, err := client.GetIssue(ctx, repo, 1)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditional).To(HaveKeyWithValue("/repos/owner/repo/issues/1", false))
+
+		_, err = client.GetIssue(ctx, repo, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditional).To(HaveKeyWithValue("/repos/owner/repo/issues/3", true))
+
+		time.Sleep(60 * time.Millisecond)
+		_, err = client.GetIssue(ctx, repo, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditional).To(HaveKeyWithValue("/repos/owner/repo/issues/3", false))
+	})
+
+	It("should forget the stored responses of closed and missing issues", func() {
+		deleted := false
+		mux.HandleFunc("/repos/owner/repo/issues/3", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPatch {
+				_, _ = w.Write([]byte(`{"number": 3, "state": "closed"}`))
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"number": 3, "state": "open"}`))
+		})
+		mux.HandleFunc("/repos/owner/repo/issues/4", func(w http.ResponseWriter, r *http.Request) {
+			if deleted {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"number": 4, "state": "open"}`))
+		})
+
+		for _, number := range []int{3, 4} {
+			_, err := client.GetIssue(ctx, repo, number)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(client.ETagEntries()).To(Equal(2))
+
+		_, err := client.CloseIssue(ctx, repo, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.ETagEntries()).To(Equal(1))
+
+		deleted = true
+		_, err = client.GetIssue(ctx, repo, 4)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+		Expect(client.ETagEntries()).To(BeZero())
+	})
+
+	It("should only replay a stored response to tokens github authorizes", func() {
+		mux.HandleFunc("/repos/owner/repo/issues/8", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"number": 8, "title": "cached"}`))
+		})
+
+		token := "token"
+		client = github.NewClient(github.Options{
+			BaseURL: server.URL,
+			Token: func(ctx context.Context) (string, error) {
+				return token, nil
+			},
+		})
+
+		_, err := client.GetIssue(ctx, repo, 8)
+		Expect(err).NotTo(HaveOccurred())
+
+		token = "other-token"
+		_, err = client.GetIssue(ctx, repo, 8)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+	})
+})
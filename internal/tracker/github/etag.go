@@ -0,0 +1,141 @@
+package github
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// defaultETagCacheSize is used when Options.ETagCacheSize is not set.
+const defaultETagCacheSize = 1000
+
+// cachedResponse is what is needed to answer a GET again when github replies 304 Not Modified.
+type cachedResponse struct {
+	url      string
+	etag     string
+	body     []byte
+	link     string
+	storedAt time.Time
+}
+
+// etagCache holds the last response of every GET keyed by URL, the least recently used responses are evicted
+// beyond maxEntries and responses older than ttl are dropped when they are looked up (a zero ttl never expires them).
+type etagCache struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newETagCache(maxEntries int, ttl time.Duration) *etagCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultETagCacheSize
+	}
+
+	return &etagCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    map[string]*list.Element{},
+	}
+}
+
+func (e *etagCache) load(url string) *cachedResponse {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	element, ok := e.entries[url]
+	if !ok {
+		return nil
+	}
+
+	cached := element.Value.(*cachedResponse)
+	if e.ttl > 0 && e.now().Sub(cached.storedAt) >= e.ttl {
+		e.remove(element)
+		return nil
+	}
+
+	e.order.MoveToFront(element)
+	return cached
+}
+
+func (e *etagCache) store(cached *cachedResponse) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cached.storedAt = e.now()
+	if element, ok := e.entries[cached.url]; ok {
+		element.Value = cached
+		e.order.MoveToFront(element)
+		return
+	}
+
+	e.entries[cached.url] = e.order.PushFront(cached)
+	for e.order.Len() > e.maxEntries {
+		e.remove(e.order.Back())
+	}
+}
+
+// forget drops the response of url and of every URL under it (e.g. the timeline and comments of an issue).
+func (e *etagCache) forget(url string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key, element := range e.entries {
+		if key == url || strings.HasPrefix(key, url+"/") || strings.HasPrefix(key, url+"?") {
+			e.remove(element)
+		}
+	}
+}
+
+func (e *etagCache) remove(element *list.Element) {
+	e.order.Remove(element)
+	delete(e.entries, element.Value.(*cachedResponse).url)
+}
+
+// get makes a conditional GET with the ETag of the previous response for the same URL,
+// a 304 does not count against the rate limit and is answered from the stored body.
+// Responses are shared between tokens: the request still carries the caller's token and github answers
+// 404 instead of 304 to a token that may not read the resource, so the stored body is only replayed to authorized tokens.
+// It returns the Link header of the response for pagination.
+func (c *Client) get(ctx context.Context, url string, result any) (string, error) {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	cached := c.etags.load(url)
+	if cached != nil {
+		req.SetHeader("If-None-Match", cached.etag)
+	}
+
+	res, err := req.Get(url)
+	if err == nil && cached != nil && res.StatusCode() == http.StatusNotModified {
+		return cached.link, json.Unmarshal(cached.body, result)
+	}
+
+	if err = checkResponse(res, err); err != nil {
+		if errors.Is(err, tracker.ErrNotFound) {
+			c.etags.forget(url)
+		}
+
+		return "", err
+	}
+
+	if etag := res.Header().Get("ETag"); etag != "" {
+		c.etags.store(&cachedResponse{url: url, etag: etag, body: res.Body(), link: res.Header().Get("Link")})
+	}
+
+	return res.Header().Get("Link"), json.Unmarshal(res.Body(), result)
+}
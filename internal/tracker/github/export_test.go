@@ -0,0 +1,9 @@
+package github
+
+// ETagEntries is the number of responses stored for conditional requests.
+func (c *Client) ETagEntries() int {
+	c.etags.mu.Lock()
+	defer c.etags.mu.Unlock()
+
+	return c.etags.order.Len()
+}
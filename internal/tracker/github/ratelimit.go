@@ -0,0 +1,123 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/metrics"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// secondaryRateLimitWait is used when github reports a secondary rate limit without a Retry-After header,
+// github asks clients to wait at least a minute in that case.
+const secondaryRateLimitWait = time.Minute
+
+// rateLimit is the quota github reported on the last response made with a token.
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+// recordRateLimit stores the X-RateLimit headers of a response against the token that made it,
+// the quotas whose window has reset are dropped so rotated tokens do not pile up.
+func (c *Client) recordRateLimit(res *resty.Response) {
+	remainingHeader := res.Header().Get("X-RateLimit-Remaining")
+	if remainingHeader == "" {
+		return
+	}
+
+	remaining, err := strconv.Atoi(remainingHeader)
+	if err != nil {
+		return
+	}
+
+	fingerprint := tokenFingerprint(res.Request.Header.Get("Authorization"))
+	resource := res.Header().Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = "core"
+	}
+
+	metrics.RateLimitRemaining.WithLabelValues(c.host, resource).Set(float64(remaining))
+
+	// Only the core quota gates every call, search and graphql have their own windows.
+	if resource != "core" {
+		return
+	}
+
+	c.rateLimitsMu.Lock()
+	defer c.rateLimitsMu.Unlock()
+
+	now := time.Now()
+	for key, limit := range c.rateLimits {
+		if now.After(limit.reset) {
+			delete(c.rateLimits, key)
+		}
+	}
+
+	c.rateLimits[fingerprint] = rateLimit{remaining: remaining, reset: unixHeader(res.Header(), "X-RateLimit-Reset")}
+}
+
+// checkRateLimit fails fast while a token is known to have no quota left, instead of spending a request on a 403.
+func (c *Client) checkRateLimit(token string) error {
+	c.rateLimitsMu.Lock()
+	defer c.rateLimitsMu.Unlock()
+
+	fingerprint := tokenFingerprint("Bearer " + token)
+	limit, ok := c.rateLimits[fingerprint]
+	if !ok {
+		return nil
+	}
+
+	if !time.Now().Before(limit.reset) {
+		delete(c.rateLimits, fingerprint)
+		return nil
+	}
+
+	if limit.remaining <= 0 {
+		return &tracker.RateLimitError{ResetAt: limit.reset}
+	}
+
+	return nil
+}
+
+// rateLimitError recognizes a 403 or 429 caused by the primary or the secondary rate limiter, nil for any other refusal.
+func rateLimitError(res *resty.Response) *tracker.RateLimitError {
+	if retryAfter := res.Header().Get("Retry-After"); retryAfter != "" {
+		seconds, err := strconv.Atoi(retryAfter)
+		if err == nil {
+			return &tracker.RateLimitError{ResetAt: time.Now().Add(time.Duration(seconds) * time.Second), Secondary: true}
+		}
+	}
+
+	if res.Header().Get("X-RateLimit-Remaining") == "0" {
+		return &tracker.RateLimitError{ResetAt: unixHeader(res.Header(), "X-RateLimit-Reset")}
+	}
+
+	if errorResponse, ok := res.Error().(*ErrorResponse); ok && strings.Contains(strings.ToLower(errorResponse.Message), "secondary rate limit") {
+		return &tracker.RateLimitError{ResetAt: time.Now().Add(secondaryRateLimitWait), Secondary: true}
+	}
+
+	return nil
+}
+
+// tokenFingerprint identifies a token in the quota map without keeping the token itself.
+func tokenFingerprint(authorization string) string {
+	sum := sha256.Sum256([]byte(authorization))
+
+	return hex.EncodeToString(sum[:4])
+}
+
+func unixHeader(header http.Header, key string) time.Time {
+	seconds, err := strconv.ParseInt(header.Get(key), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// User is the subset of the github user object shared by every payload that embeds one.
+type User struct {
+	Login   string `json:"login"`
+	ID      int64  `json:"id"`
+	Type    string `json:"type"`
+	HTMLURL string `json:"html_url"`
+}
+
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+type Milestone struct {
+	ID     int64  `json:"id"`
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+}
+
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    User   `json:"owner"`
+}
+
+type PullRequestLink struct {
+	URL      string     `json:"url"`
+	HTMLURL  string     `json:"html_url"`
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// Issue mirrors the response of the github issues API.
+type Issue struct {
+	ID          int64            `json:"id"`
+	NodeID      string           `json:"node_id"`
+	Number      int              `json:"number"`
+	Title       string           `json:"title"`
+	Body        string           `json:"body"`
+	State       string           `json:"state"`
+	StateReason string           `json:"state_reason"`
+	Locked      bool             `json:"locked"`
+	HTMLURL     string           `json:"html_url"`
+	URL         string           `json:"url"`
+	User        *User            `json:"user"`
+	Labels      []Label          `json:"labels"`
+	Assignees   []User           `json:"assignees"`
+	Milestone   *Milestone       `json:"milestone"`
+	Comments    int              `json:"comments"`
+	PullRequest *PullRequestLink `json:"pull_request"`
+	Repository  *Repository      `json:"repository"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	ClosedAt    *time.Time       `json:"closed_at"`
+}
+
+// IssueRequest is the body of the create and update issue endpoints.
+type IssueRequest struct {
+	Title       *string  `json:"title,omitempty"`
+	Body        *string  `json:"body,omitempty"`
+	State       *string  `json:"state,omitempty"`
+	StateReason *string  `json:"state_reason,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	Assignees   []string `json:"assignees,omitempty"`
+	Milestone   *int     `json:"milestone,omitempty"`
+}
+
+// CommentRequest is the body of the create issue comment endpoint.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// LockRequest is the body of the lock issue endpoint.
+type LockRequest struct {
+	LockReason string `json:"lock_reason,omitempty"`
+}
+
+// TimelineEvent mirrors a single entry of the issue timeline API, only the fields needed to find linked pull requests are kept.
+type TimelineEvent struct {
+	Event     string          `json:"event"`
+	Actor     *User           `json:"actor"`
+	Source    *TimelineSource `json:"source"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// TimelineSource is the issue or pull request that referenced the issue.
+type TimelineSource struct {
+	Type  string `json:"type"`
+	Issue *Issue `json:"issue"`
+}
+
+// GraphQLRequest is the body of the graphql endpoint.
+type GraphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// GraphQLError is one of the errors github returns with a 200 when a graphql query fails.
+type GraphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// ClosingPullRequestsResponse is the response of closingPullRequestsQuery.
+type ClosingPullRequestsResponse struct {
+	Data struct {
+		Repository *struct {
+			Issue *struct {
+				ClosedByPullRequestsReferences struct {
+					Nodes []GraphQLPullRequest `json:"nodes"`
+				} `json:"closedByPullRequestsReferences"`
+			} `json:"issue"`
+		} `json:"repository"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+// GraphQLPullRequest is the subset of the graphql PullRequest object needed to record a linked pull request.
+type GraphQLPullRequest struct {
+	Number     int       `json:"number"`
+	URL        string    `json:"url"`
+	State      string    `json:"state"`
+	Merged     bool      `json:"merged"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Repository struct {
+		Name  string `json:"name"`
+		Owner struct {
+			Login string `json:"login"`
+		} `json:"owner"`
+	} `json:"repository"`
+}
+
+// ErrorResponse is the body github returns alongside a non 2xx status.
+type ErrorResponse struct {
+	Message          string `json:"message"`
+	DocumentationURL string `json:"documentation_url"`
+}
+
+func (i *Issue) toTracker() *tracker.Issue {
+	issue := &tracker.Issue{
+		Number:      i.Number,
+		Title:       i.Title,
+		Body:        i.Body,
+		State:       i.State,
+		StateReason: i.StateReason,
+		Locked:      i.Locked,
+		HTMLURL:     i.HTMLURL,
+		CreatedAt:   i.CreatedAt,
+		UpdatedAt:   i.UpdatedAt,
+		ClosedAt:    i.ClosedAt,
+	}
+
+	for _, label := range i.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.Login)
+	}
+
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+
+	return issue
+}
+
+// linkedPullRequest returns the pull request a cross-referenced event links to, nil when the source is a plain issue.
+func (e *TimelineEvent) linkedPullRequest(repo tracker.Repository) *tracker.PullRequest {
+	if e.Source == nil || e.Source.Issue == nil || e.Source.Issue.PullRequest == nil {
+		return nil
+	}
+
+	source := e.Source.Issue
+	if source.Repository != nil {
+		repo = tracker.Repository{Owner: source.Repository.Owner.Login, Name: source.Repository.Name}
+	}
+
+	return &tracker.PullRequest{
+		Repository: repo,
+		Number:     source.Number,
+		HTMLURL:    source.HTMLURL,
+		State:      source.State,
+		Merged:     source.PullRequest.MergedAt != nil,
+		LinkedAt:   e.CreatedAt,
+	}
+}
+
+// toTracker converts a pull request closing the issue, graphql does not expose when it was linked
+// so the creation of the pull request stands in for it.
+func (p *GraphQLPullRequest) toTracker() tracker.PullRequest {
+	state := "open"
+	if p.State != "OPEN" {
+		state = "closed"
+	}
+
+	return tracker.PullRequest{
+		Repository: tracker.Repository{Owner: p.Repository.Owner.Login, Name: p.Repository.Name},
+		Number:     p.Number,
+		HTMLURL:    p.URL,
+		State:      state,
+		Merged:     p.Merged,
+		LinkedAt:   p.CreatedAt,
+	}
+}
+
+// newIssueRequest converts a tracker request, the milestone title has to be resolved by the caller.
+func newIssueRequest(request tracker.IssueRequest, milestoneNumber *int) IssueRequest {
+	return IssueRequest{
+		Title:       request.Title,
+		Body:        request.Body,
+		State:       request.State,
+		StateReason: request.StateReason,
+		Labels:      request.Labels,
+		Assignees:   request.Assignees,
+		Milestone:   milestoneNumber,
+	}
+}
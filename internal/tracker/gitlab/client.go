@@ -0,0 +1,356 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// defaultPageSize is used when Options.PageSize is not set, 100 is the maximum gitlab allows.
+const defaultPageSize = 100
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL of the gitlab REST v4 API (e.g. https://gitlab.com/api/v4).
+	BaseURL string
+	// PageSize is the number of items requested per page when listing.
+	PageSize int
+	// Token is a personal, project or group access token authenticating every request.
+	Token tracker.TokenSource
+	// Transport sends the requests (e.g. a metrics.Transport), defaults to the resty transport.
+	Transport http.RoundTripper
+}
+
+// Client is the gitlab REST v4 API implementation of tracker.IssueTracker.
+// A tracker.Repository maps to the project at <owner>/<name>, the owner may be a nested group path.
+type Client struct {
+	baseURL  string
+	pageSize int
+	token    tracker.TokenSource
+	resty    *resty.Client
+
+	// userIDs caches the id of every username resolved for assignees, usernames of a host never move to another id.
+	userIDs sync.Map
+}
+
+var _ tracker.IssueTracker = &Client{}
+
+func NewClient(options Options) *Client {
+	pageSize := options.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	return &Client{
+		baseURL:  strings.TrimSuffix(options.BaseURL, "/"),
+		pageSize: pageSize,
+		token:    options.Token,
+		resty:    resty.NewWithClient(&http.Client{Transport: options.Transport}),
+	}
+}
+
+// ListIssues follows the X-Next-Page header through every page.
+func (c *Client) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	query := url.Values{}
+	switch options.State {
+	case "", "open":
+		query.Set("state", "opened")
+	case "closed":
+		query.Set("state", "closed")
+	}
+
+	gitlabIssues, err := listAll[Issue](ctx, c, c.projectURL(repo, "/issues"), query)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []tracker.Issue
+	for i := range gitlabIssues {
+		issues = append(issues, *gitlabIssues[i].toTracker())
+	}
+
+	return issues, nil
+}
+
+func (c *Client) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	gitlabIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetResult(gitlabIssue).Get(c.projectURL(repo, fmt.Sprintf("/issues/%d", number)))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return gitlabIssue.toTracker(), nil
+}
+
+func (c *Client) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	gitlabIssue := &Issue{}
+
+	body, err := c.newIssueRequest(ctx, repo, request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(body).SetResult(gitlabIssue).Post(c.projectURL(repo, "/issues"))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return gitlabIssue.toTracker(), nil
+}
+
+func (c *Client) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	body, err := c.newIssueRequest(ctx, repo, request)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.editIssue(ctx, repo, number, body)
+}
+
+func (c *Client) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	return c.UpdateIssue(ctx, repo, number, tracker.IssueRequest{State: tracker.StringPtr("closed")})
+}
+
+// ListLinkedPullRequests returns the merge requests gitlab relates to the issue, mentioning or closing it.
+func (c *Client) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	mergeRequests, err := listAll[MergeRequest](ctx, c, c.projectURL(repo, fmt.Sprintf("/issues/%d/related_merge_requests", number)), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var pullRequests []tracker.PullRequest
+	for i := range mergeRequests {
+		pullRequests = append(pullRequests, mergeRequests[i].toTracker(repo))
+	}
+
+	return pullRequests, nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetBody(NoteRequest{Body: body}).Post(c.projectURL(repo, fmt.Sprintf("/issues/%d/notes", number)))
+	return checkResponse(res, err)
+}
+
+// LockIssue locks the discussion of an issue.
+func (c *Client) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	locked := true
+
+	_, err := c.editIssue(ctx, repo, number, IssueRequest{DiscussionLocked: &locked})
+	return err
+}
+
+func (c *Client) editIssue(ctx context.Context, repo tracker.Repository, number int, body IssueRequest) (*tracker.Issue, error) {
+	gitlabIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(body).SetResult(gitlabIssue).Put(c.projectURL(repo, fmt.Sprintf("/issues/%d", number)))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return gitlabIssue.toTracker(), nil
+}
+
+// newIssueRequest converts a tracker request, resolving assignee usernames and the milestone title to gitlab ids.
+// Gitlab has no close reason so the state reason is dropped.
+func (c *Client) newIssueRequest(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (IssueRequest, error) {
+	body := IssueRequest{
+		Title:       request.Title,
+		Description: request.Body,
+	}
+
+	if request.State != nil {
+		stateEvent := "reopen"
+		if *request.State == "closed" {
+			stateEvent = "close"
+		}
+		body.StateEvent = &stateEvent
+	}
+
+	if len(request.Labels) != 0 {
+		labels := strings.Join(request.Labels, ",")
+		body.Labels = &labels
+	}
+
+	for _, username := range request.Assignees {
+		userID, err := c.resolveUser(ctx, username)
+		if err != nil {
+			return IssueRequest{}, err
+		}
+
+		body.AssigneeIDs = append(body.AssigneeIDs, userID)
+	}
+
+	if request.Milestone != nil {
+		milestoneID, err := c.resolveMilestone(ctx, repo, *request.Milestone)
+		if err != nil {
+			return IssueRequest{}, err
+		}
+
+		body.MilestoneID = &milestoneID
+	}
+
+	return body, nil
+}
+
+// resolveUser returns the id of the user with the given username.
+func (c *Client) resolveUser(ctx context.Context, username string) (int, error) {
+	if userID, ok := c.userIDs.Load(username); ok {
+		return userID.(int), nil
+	}
+
+	var users []User
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := req.SetQueryParam("username", username).SetResult(&users).Get(fmt.Sprintf("%s/users", c.baseURL))
+	if err = checkResponse(res, err); err != nil {
+		return 0, err
+	}
+
+	for _, user := range users {
+		if strings.EqualFold(user.Username, username) {
+			c.userIDs.Store(username, user.ID)
+			return user.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("user %q does not exist", username)
+}
+
+// resolveMilestone returns the id of the project milestone with the given title.
+func (c *Client) resolveMilestone(ctx context.Context, repo tracker.Repository, title string) (int, error) {
+	milestones, err := listAll[Milestone](ctx, c, c.projectURL(repo, "/milestones"), url.Values{"title": {title}})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, milestone := range milestones {
+		if milestone.Title == title {
+			return milestone.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("milestone %q does not exist in %s", title, repo)
+}
+
+// listAll requests every page of endpoint, following the X-Next-Page header gitlab sets until the last page.
+func listAll[T any](ctx context.Context, c *Client, endpoint string, query url.Values) ([]T, error) {
+	var items []T
+
+	query.Set("per_page", strconv.Itoa(c.pageSize))
+	for page := "1"; page != ""; {
+		var pageItems []T
+
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("page", page)
+		res, err := req.SetQueryParamsFromValues(query).SetResult(&pageItems).Get(endpoint)
+		if err = checkResponse(res, err); err != nil {
+			return nil, err
+		}
+
+		items = append(items, pageItems...)
+		page = res.Header().Get("X-Next-Page")
+	}
+
+	return items, nil
+}
+
+func (c *Client) newRequest(ctx context.Context) (*resty.Request, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.resty.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetHeader("PRIVATE-TOKEN", token).
+		SetError(&ErrorResponse{}).
+		ForceContentType("application/json"), nil
+}
+
+// projectURL addresses a project by its url encoded path, which gitlab accepts wherever it takes a project id.
+func (c *Client) projectURL(repo tracker.Repository, path string) string {
+	return fmt.Sprintf("%s/projects/%s%s", c.baseURL, url.PathEscape(repo.String()), path)
+}
+
+// checkResponse folds transport errors and non 2xx statuses into a single error.
+func checkResponse(res *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode() {
+	case http.StatusUnauthorized:
+		return tracker.ErrBadCredentials
+	case http.StatusNotFound:
+		return tracker.ErrNotFound
+	case http.StatusTooManyRequests:
+		return rateLimitError(res)
+	}
+
+	if res.IsError() {
+		message := res.Status()
+		if errorResponse, ok := res.Error().(*ErrorResponse); ok {
+			if errorResponse.Message != nil {
+				message = fmt.Sprint(errorResponse.Message)
+			} else if errorResponse.Error != "" {
+				message = errorResponse.Error
+			}
+		}
+
+		return fmt.Errorf("gitlab %s %s failed with status %d: %s", res.Request.Method, res.Request.URL, res.StatusCode(), message)
+	}
+
+	return nil
+}
+
+// rateLimitError reads when a throttled request may be retried, from RateLimit-Reset or else Retry-After.
+func rateLimitError(res *resty.Response) *tracker.RateLimitError {
+	if reset, err := strconv.ParseInt(res.Header().Get("RateLimit-Reset"), 10, 64); err == nil {
+		return &tracker.RateLimitError{ResetAt: time.Unix(reset, 0)}
+	}
+
+	if seconds, err := strconv.Atoi(res.Header().Get("Retry-After")); err == nil {
+		return &tracker.RateLimitError{ResetAt: time.Now().Add(time.Duration(seconds) * time.Second)}
+	}
+
+	return &tracker.RateLimitError{ResetAt: time.Now().Add(time.Minute)}
+}
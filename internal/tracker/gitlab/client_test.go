@@ -0,0 +1,174 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/gitlab"
+)
+
+var _ = Describe("Gitlab Client", func() {
+	var server *httptest.Server
+	var handlers map[string]http.HandlerFunc
+	var client *gitlab.Client
+	repo := tracker.Repository{Host: "gitlab.example.com", Owner: "group/subgroup", Name: "project"}
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		// Project paths are sent url encoded, so the handlers are keyed by method and escaped path instead of going through a ServeMux.
+		handlers = map[string]http.HandlerFunc{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler, ok := handlers[r.Method+" "+r.URL.EscapedPath()]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "404 Not found"}`))
+				return
+			}
+
+			handler(w, r)
+		}))
+		client = gitlab.NewClient(gitlab.Options{
+			BaseURL:  server.URL + "/api/v4",
+			PageSize: 2,
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should send the token and map the created issue", func() {
+		handlers["GET /api/v4/users"] = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("username")).To(Equal("octocat"))
+
+			_, _ = w.Write([]byte(`[{"id": 42, "username": "octocat"}]`))
+		}
+		handlers["GET /api/v4/projects/group%2Fsubgroup%2Fproject/milestones"] = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("title")).To(Equal("v1"))
+
+			_, _ = w.Write([]byte(`[{"id": 7, "iid": 1, "title": "v1"}]`))
+		}
+		handlers["POST /api/v4/projects/group%2Fsubgroup%2Fproject/issues"] = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Header.Get("PRIVATE-TOKEN")).To(Equal("token"))
+
+			body := gitlab.IssueRequest{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			Expect(*body.Title).To(Equal("title"))
+			Expect(*body.Description).To(Equal("body"))
+			Expect(*body.Labels).To(Equal("bug,p1"))
+			Expect(body.AssigneeIDs).To(Equal([]int{42}))
+			Expect(*body.MilestoneID).To(Equal(7))
+			Expect(body.StateEvent).To(BeNil())
+
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"iid": 3, "title": "title", "description": "body", "state": "opened", "web_url": "https://gitlab.example.com/group/subgroup/project/-/issues/3",
+				"labels": ["bug", "p1"], "assignees": [{"id": 42, "username": "octocat"}], "milestone": {"id": 7, "title": "v1"}}`))
+		}
+
+		issue, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{
+			Title:     tracker.StringPtr("title"),
+			Body:      tracker.StringPtr("body"),
+			Labels:    []string{"bug", "p1"},
+			Assignees: []string{"octocat"},
+			Milestone: tracker.StringPtr("v1"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.Number).To(Equal(3))
+		Expect(issue.State).To(Equal("open"))
+		Expect(issue.Body).To(Equal("body"))
+		Expect(issue.Labels).To(Equal([]string{"bug", "p1"}))
+		Expect(issue.Assignees).To(Equal([]string{"octocat"}))
+		Expect(issue.Milestone).To(Equal("v1"))
+	})
+
+	It("should follow the X-Next-Page header", func() {
+		handlers["GET /api/v4/projects/group%2Fsubgroup%2Fproject/issues"] = func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Has("state")).To(BeFalse())
+			Expect(r.URL.Query().Get("per_page")).To(Equal("2"))
+
+			if r.URL.Query().Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				_, _ = w.Write([]byte(`[{"iid": 1, "state": "opened"}, {"iid": 2, "state": "closed"}]`))
+				return
+			}
+
+			w.Header().Set("X-Next-Page", "")
+			_, _ = w.Write([]byte(`[{"iid": 3, "state": "opened"}]`))
+		}
+
+		issues, err := client.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[1].State).To(Equal("closed"))
+		Expect(issues[2].Number).To(Equal(3))
+	})
+
+	It("should close and lock an issue with state and discussion edits", func() {
+		var bodies []map[string]any
+		handlers["PUT /api/v4/projects/group%2Fsubgroup%2Fproject/issues/3"] = func(w http.ResponseWriter, r *http.Request) {
+			body := map[string]any{}
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			bodies = append(bodies, body)
+
+			_, _ = w.Write([]byte(`{"iid": 3, "state": "closed", "discussion_locked": true}`))
+		}
+
+		issue, err := client.CloseIssue(ctx, repo, 3)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.State).To(Equal("closed"))
+
+		Expect(client.LockIssue(ctx, repo, 3)).To(Succeed())
+		Expect(bodies).To(Equal([]map[string]any{{"state_event": "close"}, {"discussion_locked": true}}))
+	})
+
+	It("should map related merge requests to pull requests", func() {
+		handlers["GET /api/v4/projects/group%2Fsubgroup%2Fproject/issues/4/related_merge_requests"] = func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`[
+				{"iid": 10, "state": "opened", "web_url": "https://gitlab.example.com/group/subgroup/project/-/merge_requests/10", "references": {"full": "group/subgroup/project!10"}},
+				{"iid": 11, "state": "merged", "web_url": "https://gitlab.example.com/other/fork/-/merge_requests/11", "references": {"full": "other/fork!11"}}
+			]`))
+		}
+
+		pullRequests, err := client.ListLinkedPullRequests(ctx, repo, 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pullRequests).To(Equal([]tracker.PullRequest{
+			{Repository: repo, Number: 10, HTMLURL: "https://gitlab.example.com/group/subgroup/project/-/merge_requests/10", State: "open"},
+			{Repository: tracker.Repository{Host: "gitlab.example.com", Owner: "other", Name: "fork"}, Number: 11, HTMLURL: "https://gitlab.example.com/other/fork/-/merge_requests/11", State: "closed", Merged: true},
+		}))
+	})
+
+	It("should map 401, 404 and 429 to the tracker errors", func() {
+		resetAt := time.Now().Add(time.Minute).Truncate(time.Second)
+		handlers["GET /api/v4/projects/group%2Fsubgroup%2Fproject/issues/1"] = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message": "401 Unauthorized"}`))
+		}
+		handlers["GET /api/v4/projects/group%2Fsubgroup%2Fproject/issues/5"] = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("RateLimit-Reset", fmt.Sprintf("%d", resetAt.Unix()))
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+
+		_, err := client.GetIssue(ctx, repo, 1)
+		Expect(err).To(MatchError(tracker.ErrBadCredentials))
+
+		_, err = client.GetIssue(ctx, repo, 2)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+
+		var rateLimitErr *tracker.RateLimitError
+		_, err = client.GetIssue(ctx, repo, 5)
+		Expect(errors.As(err, &rateLimitErr)).To(BeTrue())
+		Expect(rateLimitErr.ResetAt).To(BeTemporally("==", resetAt))
+	})
+})
@@ -0,0 +1,146 @@
+package gitlab
+
+import (
+	"strings"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// User is the subset of the gitlab user object shared by every payload that embeds one.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	WebURL   string `json:"web_url"`
+}
+
+type Milestone struct {
+	ID    int    `json:"id"`
+	IID   int    `json:"iid"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// Issue mirrors the response of the gitlab issues API, the project scoped iid is the issue number.
+type Issue struct {
+	ID               int        `json:"id"`
+	IID              int        `json:"iid"`
+	ProjectID        int        `json:"project_id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description"`
+	State            string     `json:"state"`
+	DiscussionLocked bool       `json:"discussion_locked"`
+	WebURL           string     `json:"web_url"`
+	Labels           []string   `json:"labels"`
+	Assignees        []User     `json:"assignees"`
+	Milestone        *Milestone `json:"milestone"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	ClosedAt         *time.Time `json:"closed_at"`
+}
+
+// IssueRequest is the body of the create and edit issue endpoints.
+type IssueRequest struct {
+	Title       *string `json:"title,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// StateEvent is close or reopen, gitlab has no writable state field.
+	StateEvent *string `json:"state_event,omitempty"`
+	// Labels is a comma separated list replacing every label of the issue.
+	Labels           *string `json:"labels,omitempty"`
+	AssigneeIDs      []int   `json:"assignee_ids,omitempty"`
+	MilestoneID      *int    `json:"milestone_id,omitempty"`
+	DiscussionLocked *bool   `json:"discussion_locked,omitempty"`
+}
+
+// MergeRequest mirrors the entries of the related merge requests endpoint.
+type MergeRequest struct {
+	IID        int                    `json:"iid"`
+	ProjectID  int                    `json:"project_id"`
+	Title      string                 `json:"title"`
+	State      string                 `json:"state"`
+	WebURL     string                 `json:"web_url"`
+	MergedAt   *time.Time             `json:"merged_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	References MergeRequestReferences `json:"references"`
+}
+
+type MergeRequestReferences struct {
+	// Full is the project path followed by the merge request reference, e.g. group/project!12.
+	Full string `json:"full"`
+}
+
+// NoteRequest is the body of the create issue note endpoint.
+type NoteRequest struct {
+	Body string `json:"body"`
+}
+
+// ErrorResponse is the body gitlab returns alongside a non 2xx status, the message can be a string or an object.
+type ErrorResponse struct {
+	Message any    `json:"message"`
+	Error   string `json:"error"`
+}
+
+func (i *Issue) toTracker() *tracker.Issue {
+	issue := &tracker.Issue{
+		Number:    i.IID,
+		Title:     i.Title,
+		Body:      i.Description,
+		State:     trackerState(i.State),
+		Locked:    i.DiscussionLocked,
+		HTMLURL:   i.WebURL,
+		Labels:    i.Labels,
+		CreatedAt: i.CreatedAt,
+		UpdatedAt: i.UpdatedAt,
+		ClosedAt:  i.ClosedAt,
+	}
+
+	for _, assignee := range i.Assignees {
+		issue.Assignees = append(issue.Assignees, assignee.Username)
+	}
+
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+
+	return issue
+}
+
+// toTracker converts a merge request, merged and locked merge requests are closed.
+func (m *MergeRequest) toTracker(repo tracker.Repository) tracker.PullRequest {
+	if fullPath, _, ok := strings.Cut(m.References.Full, "!"); ok && fullPath != "" {
+		repo = projectRepository(repo.Host, fullPath)
+	}
+
+	state := "closed"
+	if m.State == "opened" {
+		state = "open"
+	}
+
+	return tracker.PullRequest{
+		Repository: repo,
+		Number:     m.IID,
+		HTMLURL:    m.WebURL,
+		State:      state,
+		Merged:     m.State == "merged",
+		LinkedAt:   m.CreatedAt,
+	}
+}
+
+// trackerState maps the gitlab issue states (opened, closed) to the tracker ones (open, closed).
+func trackerState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+
+	return state
+}
+
+// projectRepository splits a project path, the owner is the (possibly nested) namespace of the project.
+func projectRepository(host string, fullPath string) tracker.Repository {
+	index := strings.LastIndex(fullPath, "/")
+	if index < 0 {
+		return tracker.Repository{Host: host, Name: fullPath}
+	}
+
+	return tracker.Repository{Host: host, Owner: fullPath[:index], Name: fullPath[index+1:]}
+}
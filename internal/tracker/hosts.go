@@ -0,0 +1,103 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Hosts is an IssueTracker routing every call to the tracker of the repository's host, keyed by lowercase host.
+type Hosts map[string]IssueTracker
+
+// Invalidator is implemented by trackers caching remote issues.
+type Invalidator interface {
+	Invalidate(repo Repository)
+}
+
+func (h Hosts) tracker(repo Repository) (IssueTracker, error) {
+	issueTracker, ok := h[strings.ToLower(repo.Host)]
+	if !ok {
+		return nil, fmt.Errorf("no tracker is configured for host %q", repo.Host)
+	}
+
+	return issueTracker, nil
+}
+
+// Invalidate forwards to the tracker of repo's host when it caches issues.
+func (h Hosts) Invalidate(repo Repository) {
+	if invalidator, ok := h[strings.ToLower(repo.Host)].(Invalidator); ok {
+		invalidator.Invalidate(repo)
+	}
+}
+
+func (h Hosts) ListIssues(ctx context.Context, repo Repository, options ListOptions) ([]Issue, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.ListIssues(ctx, repo, options)
+}
+
+func (h Hosts) GetIssue(ctx context.Context, repo Repository, number int) (*Issue, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.GetIssue(ctx, repo, number)
+}
+
+func (h Hosts) CreateIssue(ctx context.Context, repo Repository, request IssueRequest) (*Issue, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.CreateIssue(ctx, repo, request)
+}
+
+func (h Hosts) UpdateIssue(ctx context.Context, repo Repository, number int, request IssueRequest) (*Issue, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.UpdateIssue(ctx, repo, number, request)
+}
+
+func (h Hosts) CloseIssue(ctx context.Context, repo Repository, number int) (*Issue, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.CloseIssue(ctx, repo, number)
+}
+
+func (h Hosts) ListLinkedPullRequests(ctx context.Context, repo Repository, number int) ([]PullRequest, error) {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	return issueTracker.ListLinkedPullRequests(ctx, repo, number)
+}
+
+func (h Hosts) CreateComment(ctx context.Context, repo Repository, number int, body string) error {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return err
+	}
+
+	return issueTracker.CreateComment(ctx, repo, number, body)
+}
+
+func (h Hosts) LockIssue(ctx context.Context, repo Repository, number int) error {
+	issueTracker, err := h.tracker(repo)
+	if err != nil {
+		return err
+	}
+
+	return issueTracker.LockIssue(ctx, repo, number)
+}
@@ -0,0 +1,366 @@
+package jira
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+const (
+	// defaultPageSize is used when Options.PageSize is not set, jira caps search pages at 100 anyway.
+	defaultPageSize = 50
+
+	defaultIssueType        = "Task"
+	defaultCloseTransition  = "Done"
+	defaultReopenTransition = "To Do"
+)
+
+// Options configures a Client.
+type Options struct {
+	// BaseURL of the jira REST v2 API (e.g. https://example.atlassian.net/rest/api/2).
+	BaseURL string
+	// PageSize is the number of issues requested per search page.
+	PageSize int
+	// Token authenticates every request, an <email>:<api token> pair is sent with basic auth (jira cloud)
+	// and anything else as a bearer personal access token (jira server and data center).
+	Token tracker.TokenSource
+	// Transport sends the requests (e.g. a metrics.Transport), defaults to the resty transport.
+	Transport http.RoundTripper
+	// IssueType is the issue type created when the request names none, defaults to Task.
+	IssueType string
+	// CloseTransition and ReopenTransition name the workflow transitions (or their target statuses)
+	// applied to close and reopen an issue, they default to Done and To Do.
+	CloseTransition  string
+	ReopenTransition string
+}
+
+// Client is the jira REST v2 API implementation of tracker.IssueTracker.
+// The repository name is the project key and issue numbers are the sequence of the issue keys (12 for PROJ-12).
+type Client struct {
+	baseURL          string
+	siteURL          string
+	pageSize         int
+	token            tracker.TokenSource
+	issueType        string
+	closeTransition  string
+	reopenTransition string
+	resty            *resty.Client
+}
+
+var _ tracker.IssueTracker = &Client{}
+
+func NewClient(options Options) *Client {
+	baseURL := strings.TrimSuffix(options.BaseURL, "/")
+
+	siteURL := baseURL
+	if index := strings.Index(baseURL, "/rest/"); index >= 0 {
+		siteURL = baseURL[:index]
+	}
+
+	c := &Client{
+		baseURL:          baseURL,
+		siteURL:          siteURL,
+		pageSize:         options.PageSize,
+		token:            options.Token,
+		issueType:        options.IssueType,
+		closeTransition:  options.CloseTransition,
+		reopenTransition: options.ReopenTransition,
+		resty:            resty.NewWithClient(&http.Client{Transport: options.Transport}),
+	}
+
+	if c.pageSize <= 0 {
+		c.pageSize = defaultPageSize
+	}
+	if c.issueType == "" {
+		c.issueType = defaultIssueType
+	}
+	if c.closeTransition == "" {
+		c.closeTransition = defaultCloseTransition
+	}
+	if c.reopenTransition == "" {
+		c.reopenTransition = defaultReopenTransition
+	}
+
+	return c
+}
+
+// ListIssues pages through a JQL search of the project, closed issues are the ones in the done status category.
+func (c *Client) ListIssues(ctx context.Context, repo tracker.Repository, options tracker.ListOptions) ([]tracker.Issue, error) {
+	jql := fmt.Sprintf("project = %q", repo.Name)
+	switch options.State {
+	case "", "open":
+		jql += " AND statusCategory != Done"
+	case "closed":
+		jql += " AND statusCategory = Done"
+	}
+	jql += " ORDER BY created ASC"
+
+	var issues []tracker.Issue
+	for startAt := 0; ; {
+		result := &SearchResult{}
+
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := req.SetQueryParams(map[string]string{
+			"jql":        jql,
+			"fields":     fieldList,
+			"startAt":    strconv.Itoa(startAt),
+			"maxResults": strconv.Itoa(c.pageSize),
+		}).SetResult(result).Get(c.baseURL + "/search")
+		if err = checkResponse(res, err); err != nil {
+			return nil, err
+		}
+
+		for i := range result.Issues {
+			issues = append(issues, *result.Issues[i].toTracker(c.siteURL))
+		}
+
+		startAt += len(result.Issues)
+		if len(result.Issues) == 0 || startAt >= result.Total {
+			return issues, nil
+		}
+	}
+}
+
+func (c *Client) GetIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	jiraIssue := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetQueryParam("fields", fieldList).SetResult(jiraIssue).Get(c.issueURL(repo, number, ""))
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	return jiraIssue.toTracker(c.siteURL), nil
+}
+
+// CreateIssue creates the issue in the project and transitions it when it is requested closed.
+func (c *Client) CreateIssue(ctx context.Context, repo tracker.Repository, request tracker.IssueRequest) (*tracker.Issue, error) {
+	if len(request.Assignees) != 0 {
+		return nil, fmt.Errorf("assigning jira issues: %w", tracker.ErrUnsupported)
+	}
+
+	issueType := c.issueType
+	if request.Type != nil && *request.Type != "" {
+		issueType = *request.Type
+	}
+
+	body := newIssueRequest(request)
+	body.Fields.Project = &Project{Key: repo.Name}
+	body.Fields.IssueType = &IssueType{Name: issueType}
+
+	created := &Issue{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := req.SetBody(body).SetResult(created).Post(c.baseURL + "/issue")
+	if err = checkResponse(res, err); err != nil {
+		return nil, err
+	}
+
+	number := issueNumber(created.Key)
+	if request.State != nil && *request.State == "closed" {
+		if err := c.transition(ctx, repo, number, c.closeTransition, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.GetIssue(ctx, repo, number)
+}
+
+// UpdateIssue edits the fields of the issue and applies the workflow transition matching the requested state.
+func (c *Client) UpdateIssue(ctx context.Context, repo tracker.Repository, number int, request tracker.IssueRequest) (*tracker.Issue, error) {
+	if len(request.Assignees) != 0 {
+		return nil, fmt.Errorf("assigning jira issues: %w", tracker.ErrUnsupported)
+	}
+
+	body := newIssueRequest(request)
+	if body.Fields.Summary != nil || body.Fields.Description != nil || body.Fields.Labels != nil || body.Fields.FixVersions != nil {
+		req, err := c.newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := req.SetBody(body).Put(c.issueURL(repo, number, ""))
+		if err = checkResponse(res, err); err != nil {
+			return nil, err
+		}
+	}
+
+	if request.State != nil {
+		issue, err := c.GetIssue(ctx, repo, number)
+		if err != nil {
+			return nil, err
+		}
+
+		if issue.State != *request.State {
+			closing := *request.State == "closed"
+
+			transitionName := c.reopenTransition
+			if closing {
+				transitionName = c.closeTransition
+			}
+
+			if err := c.transition(ctx, repo, number, transitionName, closing); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c.GetIssue(ctx, repo, number)
+}
+
+func (c *Client) CloseIssue(ctx context.Context, repo tracker.Repository, number int) (*tracker.Issue, error) {
+	return c.UpdateIssue(ctx, repo, number, tracker.IssueRequest{State: tracker.StringPtr("closed")})
+}
+
+// ListLinkedPullRequests returns nothing, the development panel of jira is not part of its public REST API.
+func (c *Client) ListLinkedPullRequests(ctx context.Context, repo tracker.Repository, number int) ([]tracker.PullRequest, error) {
+	return nil, nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, repo tracker.Repository, number int, body string) error {
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetBody(CommentRequest{Body: body}).Post(c.issueURL(repo, number, "/comment"))
+	return checkResponse(res, err)
+}
+
+// LockIssue is not offered by jira.
+func (c *Client) LockIssue(ctx context.Context, repo tracker.Repository, number int) error {
+	return fmt.Errorf("locking jira issues: %w", tracker.ErrUnsupported)
+}
+
+// transition applies the available transition with the given name, or leading to a status with that name.
+// When neither exists the first transition into (closing) or out of the done status category is used,
+// so workflows with other names still close and reopen.
+func (c *Client) transition(ctx context.Context, repo tracker.Repository, number int, name string, closing bool) error {
+	result := &TransitionsResult{}
+
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := req.SetResult(result).Get(c.issueURL(repo, number, "/transitions"))
+	if err = checkResponse(res, err); err != nil {
+		return err
+	}
+
+	var chosen *Transition
+	for i := range result.Transitions {
+		transition := &result.Transitions[i]
+
+		if strings.EqualFold(transition.Name, name) || strings.EqualFold(transition.To.Name, name) {
+			chosen = transition
+			break
+		}
+
+		if chosen == nil && (transition.To.StatusCategory.Key == "done") == closing {
+			chosen = transition
+		}
+	}
+
+	if chosen == nil {
+		return fmt.Errorf("no workflow transition %q is available on %s-%d", name, repo.Name, number)
+	}
+
+	req, err = c.newRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err = req.SetBody(TransitionRequest{Transition: TransitionID{ID: chosen.ID}}).Post(c.issueURL(repo, number, "/transitions"))
+	return checkResponse(res, err)
+}
+
+// newIssueRequest converts the content of a tracker request, the milestone maps to the fix version with that name.
+func newIssueRequest(request tracker.IssueRequest) IssueRequest {
+	body := IssueRequest{Fields: Fields{
+		Summary:     request.Title,
+		Description: request.Body,
+		Labels:      request.Labels,
+	}}
+
+	if request.Milestone != nil {
+		body.Fields.FixVersions = []Version{{Name: *request.Milestone}}
+	}
+
+	return body
+}
+
+func (c *Client) newRequest(ctx context.Context) (*resty.Request, error) {
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization := fmt.Sprintf("Bearer %s", token)
+	if strings.Contains(token, ":") {
+		authorization = fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(token)))
+	}
+
+	return c.resty.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetHeader("Authorization", authorization).
+		SetError(&ErrorResponse{}).
+		ForceContentType("application/json"), nil
+}
+
+func (c *Client) issueURL(repo tracker.Repository, number int, path string) string {
+	return fmt.Sprintf("%s/issue/%s-%d%s", c.baseURL, repo.Name, number, path)
+}
+
+// checkResponse folds transport errors and non 2xx statuses into a single error.
+func checkResponse(res *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	switch res.StatusCode() {
+	case http.StatusUnauthorized:
+		return tracker.ErrBadCredentials
+	case http.StatusNotFound:
+		return tracker.ErrNotFound
+	case http.StatusTooManyRequests:
+		resetAt := time.Now().Add(time.Minute)
+		if seconds, err := strconv.Atoi(res.Header().Get("Retry-After")); err == nil {
+			resetAt = time.Now().Add(time.Duration(seconds) * time.Second)
+		}
+
+		return &tracker.RateLimitError{ResetAt: resetAt}
+	}
+
+	if res.IsError() {
+		message := res.Status()
+		if errorResponse, ok := res.Error().(*ErrorResponse); ok && errorResponse.message() != "" {
+			message = errorResponse.message()
+		}
+
+		return fmt.Errorf("jira %s %s failed with status %d: %s", res.Request.Method, res.Request.URL, res.StatusCode(), message)
+	}
+
+	return nil
+}
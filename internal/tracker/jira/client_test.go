@@ -0,0 +1,157 @@
+package jira_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/jira"
+)
+
+var _ = Describe("Jira Client", func() {
+	var server *httptest.Server
+	var jiraStandIn *standIn
+	var client *jira.Client
+	repo := tracker.Repository{Host: "jira.example.com", Owner: "browse", Name: "PROJ"}
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		jiraStandIn = newStandIn("Bearer token")
+		server = httptest.NewServer(jiraStandIn)
+		client = jira.NewClient(jira.Options{
+			BaseURL:  server.URL + "/rest/api/2",
+			PageSize: 2,
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create an issue of the requested type and expose its key", func() {
+		issue, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{
+			Title:     tracker.StringPtr("summary"),
+			Body:      tracker.StringPtr("description"),
+			Labels:    []string{"backend"},
+			Milestone: tracker.StringPtr("1.0"),
+			Type:      tracker.StringPtr("Story"),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issue.Key).To(Equal("PROJ-1"))
+		Expect(issue.Number).To(Equal(1))
+		Expect(issue.Title).To(Equal("summary"))
+		Expect(issue.Body).To(Equal("description"))
+		Expect(issue.State).To(Equal("open"))
+		Expect(issue.Labels).To(Equal([]string{"backend"}))
+		Expect(issue.Milestone).To(Equal("1.0"))
+		Expect(issue.HTMLURL).To(Equal(server.URL + "/browse/PROJ-1"))
+		Expect(jiraStandIn.issues["PROJ-1"].Fields.IssueType.Name).To(Equal("Story"))
+
+		_, err = client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("default type")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jiraStandIn.issues["PROJ-2"].Fields.IssueType.Name).To(Equal("Task"))
+	})
+
+	It("should close and reopen through workflow transitions", func() {
+		created, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("summary")})
+		Expect(err).NotTo(HaveOccurred())
+
+		closed, err := client.CloseIssue(ctx, repo, created.Number)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(closed.State).To(Equal("closed"))
+		Expect(closed.ClosedAt).NotTo(BeNil())
+
+		reopened, err := client.UpdateIssue(ctx, repo, created.Number, tracker.IssueRequest{State: tracker.StringPtr("open"), Title: tracker.StringPtr("renamed")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reopened.State).To(Equal("open"))
+		Expect(reopened.Title).To(Equal("renamed"))
+		Expect(reopened.ClosedAt).To(BeNil())
+
+		_, err = client.UpdateIssue(ctx, repo, created.Number, tracker.IssueRequest{State: tracker.StringPtr("open")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jiraStandIn.transitions).To(Equal([]string{"PROJ-1 Resolve", "PROJ-1 Back to backlog"}))
+	})
+
+	It("should use the configured transitions", func() {
+		client = jira.NewClient(jira.Options{
+			BaseURL: server.URL + "/rest/api/2",
+			Token: func(ctx context.Context) (string, error) {
+				return "token", nil
+			},
+			CloseTransition:  "Resolve",
+			ReopenTransition: "Start progress",
+		})
+
+		created, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("summary"), State: tracker.StringPtr("closed")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.State).To(Equal("closed"))
+
+		_, err = client.UpdateIssue(ctx, repo, created.Number, tracker.IssueRequest{State: tracker.StringPtr("open")})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jiraStandIn.transitions).To(Equal([]string{"PROJ-1 Resolve", "PROJ-1 Start progress"}))
+	})
+
+	It("should page through the search filtered by status category", func() {
+		for _, summary := range []string{"one", "two", "three"} {
+			_, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr(summary)})
+			Expect(err).NotTo(HaveOccurred())
+		}
+		_, err := client.CloseIssue(ctx, repo, 2)
+		Expect(err).NotTo(HaveOccurred())
+
+		issues, err := client.ListIssues(ctx, repo, tracker.ListOptions{State: "all"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(3))
+		Expect(issues[2].Title).To(Equal("three"))
+
+		issues, err = client.ListIssues(ctx, repo, tracker.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(2))
+
+		issues, err = client.ListIssues(ctx, repo, tracker.ListOptions{State: "closed"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Key).To(Equal("PROJ-2"))
+	})
+
+	It("should comment, and refuse locking and assignees", func() {
+		created, err := client.CreateIssue(ctx, repo, tracker.IssueRequest{Title: tracker.StringPtr("summary")})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(client.CreateComment(ctx, repo, created.Number, "closing")).To(Succeed())
+		Expect(jiraStandIn.comments["PROJ-1"]).To(Equal([]string{"closing"}))
+
+		Expect(client.LockIssue(ctx, repo, created.Number)).To(MatchError(tracker.ErrUnsupported))
+
+		_, err = client.UpdateIssue(ctx, repo, created.Number, tracker.IssueRequest{Assignees: []string{"someone"}})
+		Expect(err).To(MatchError(tracker.ErrUnsupported))
+
+		pullRequests, err := client.ListLinkedPullRequests(ctx, repo, created.Number)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pullRequests).To(BeEmpty())
+	})
+
+	It("should send email and api token pairs with basic auth and map the errors", func() {
+		jiraStandIn.token = "Basic " + base64.StdEncoding.EncodeToString([]byte("me@example.com:api-token"))
+		client = jira.NewClient(jira.Options{
+			BaseURL: server.URL + "/rest/api/2",
+			Token: func(ctx context.Context) (string, error) {
+				return "me@example.com:api-token", nil
+			},
+		})
+
+		_, err := client.GetIssue(ctx, repo, 1)
+		Expect(err).To(MatchError(tracker.ErrNotFound))
+
+		jiraStandIn.token = "Bearer other"
+		_, err = client.GetIssue(ctx, repo, 1)
+		Expect(err).To(MatchError(tracker.ErrBadCredentials))
+	})
+})
@@ -0,0 +1,184 @@
+package jira_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker/jira"
+)
+
+// standIn is a minimal in-memory jira serving the endpoints the client uses, with a To Do -> In Progress -> Done workflow.
+type standIn struct {
+	mu          sync.Mutex
+	token       string
+	issues      map[string]*jira.Issue
+	comments    map[string][]string
+	transitions []string
+	sequence    int
+}
+
+var (
+	statusToDo       = jira.Status{Name: "To Do", StatusCategory: jira.StatusCategory{Key: "new"}}
+	statusInProgress = jira.Status{Name: "In Progress", StatusCategory: jira.StatusCategory{Key: "indeterminate"}}
+	statusDone       = jira.Status{Name: "Done", StatusCategory: jira.StatusCategory{Key: "done"}}
+
+	workflow = []jira.Transition{
+		{ID: "11", Name: "Start progress", To: statusInProgress},
+		{ID: "21", Name: "Resolve", To: statusDone},
+		{ID: "31", Name: "Back to backlog", To: statusToDo},
+	}
+)
+
+func newStandIn(token string) *standIn {
+	return &standIn{token: token, issues: map[string]*jira.Issue{}, comments: map[string][]string{}}
+}
+
+func (s *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Authorization") != s.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/rest/api/2")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && path == "/search":
+		s.search(w, r)
+	case r.Method == http.MethodPost && path == "/issue":
+		s.create(w, r)
+	case len(parts) >= 2 && parts[0] == "issue":
+		issue, ok := s.issues[parts[1]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, jira.ErrorResponse{ErrorMessages: []string{"Issue does not exist or you do not have permission to see it."}})
+			return
+		}
+
+		s.issue(w, r, issue, strings.Join(parts[2:], "/"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *standIn) create(w http.ResponseWriter, r *http.Request) {
+	request := jira.IssueRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Fields.Summary == nil || request.Fields.Project == nil {
+		writeJSON(w, http.StatusBadRequest, jira.ErrorResponse{Errors: map[string]string{"summary": "You must specify a summary of the issue."}})
+		return
+	}
+
+	s.sequence++
+	now := &jira.Time{Time: time.Now().Truncate(time.Millisecond)}
+	issue := &jira.Issue{ID: strconv.Itoa(10000 + s.sequence), Key: fmt.Sprintf("%s-%d", request.Fields.Project.Key, s.sequence), Fields: request.Fields}
+	issue.Fields.Status = &statusToDo
+	issue.Fields.Created = now
+	issue.Fields.Updated = now
+	s.issues[issue.Key] = issue
+
+	writeJSON(w, http.StatusCreated, jira.Issue{ID: issue.ID, Key: issue.Key})
+}
+
+func (s *standIn) issue(w http.ResponseWriter, r *http.Request, issue *jira.Issue, subresource string) {
+	switch {
+	case r.Method == http.MethodGet && subresource == "":
+		writeJSON(w, http.StatusOK, issue)
+	case r.Method == http.MethodPut && subresource == "":
+		request := jira.IssueRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Fields.Summary != nil {
+			issue.Fields.Summary = request.Fields.Summary
+		}
+		if request.Fields.Description != nil {
+			issue.Fields.Description = request.Fields.Description
+		}
+		if request.Fields.Labels != nil {
+			issue.Fields.Labels = request.Fields.Labels
+		}
+		if request.Fields.FixVersions != nil {
+			issue.Fields.FixVersions = request.Fields.FixVersions
+		}
+		issue.Fields.Updated = &jira.Time{Time: time.Now().Truncate(time.Millisecond)}
+
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && subresource == "transitions":
+		writeJSON(w, http.StatusOK, jira.TransitionsResult{Transitions: workflow})
+	case r.Method == http.MethodPost && subresource == "transitions":
+		request := jira.TransitionRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+
+		for _, transition := range workflow {
+			if transition.ID == request.Transition.ID {
+				status := transition.To
+				issue.Fields.Status = &status
+				issue.Fields.ResolutionDate = nil
+				if status.StatusCategory.Key == "done" {
+					issue.Fields.ResolutionDate = &jira.Time{Time: time.Now().Truncate(time.Millisecond)}
+				}
+				s.transitions = append(s.transitions, issue.Key+" "+transition.Name)
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		writeJSON(w, http.StatusBadRequest, jira.ErrorResponse{ErrorMessages: []string{"Transition id is not valid"}})
+	case r.Method == http.MethodPost && subresource == "comment":
+		request := jira.CommentRequest{}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		s.comments[issue.Key] = append(s.comments[issue.Key], request.Body)
+
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// search understands the queries the client builds: a project and an optional statusCategory filter, one page at a time.
+func (s *standIn) search(w http.ResponseWriter, r *http.Request) {
+	jql := r.URL.Query().Get("jql")
+	startAt, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+	maxResults, _ := strconv.Atoi(r.URL.Query().Get("maxResults"))
+
+	var matching []jira.Issue
+	for sequence := 1; sequence <= s.sequence; sequence++ {
+		for _, issue := range s.issues {
+			if issueSequence(issue.Key) != sequence || !strings.Contains(jql, fmt.Sprintf("project = %q", issue.Fields.Project.Key)) {
+				continue
+			}
+
+			done := issue.Fields.Status.StatusCategory.Key == "done"
+			if (strings.Contains(jql, "statusCategory != Done") && done) || (strings.Contains(jql, "statusCategory = Done") && !done) {
+				continue
+			}
+
+			matching = append(matching, *issue)
+		}
+	}
+
+	page := []jira.Issue{}
+	if startAt < len(matching) {
+		page = matching[startAt:min(startAt+maxResults, len(matching))]
+	}
+
+	writeJSON(w, http.StatusOK, jira.SearchResult{StartAt: startAt, MaxResults: maxResults, Total: len(matching), Issues: page})
+}
+
+func issueSequence(key string) int {
+	sequence, _ := strconv.Atoi(key[strings.LastIndex(key, "-")+1:])
+	return sequence
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,188 @@
+package jira
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/idoSharon1/githubIssue-operator/internal/tracker"
+)
+
+// timeLayout is the layout jira formats timestamps with, its zone offset has no colon so RFC 3339 parsing fails.
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Time decodes jira timestamps.
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		return nil
+	}
+
+	parsed, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return err
+	}
+
+	t.Time = parsed
+	return nil
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(t.Format(timeLayout))), nil
+}
+
+type Project struct {
+	Key string `json:"key"`
+}
+
+type IssueType struct {
+	Name string `json:"name"`
+}
+
+type Version struct {
+	Name string `json:"name"`
+}
+
+type StatusCategory struct {
+	// Key is new, indeterminate or done.
+	Key string `json:"key"`
+}
+
+type Status struct {
+	Name           string         `json:"name"`
+	StatusCategory StatusCategory `json:"statusCategory"`
+}
+
+// Fields holds the issue fields requested through fieldList.
+type Fields struct {
+	Project        *Project   `json:"project,omitempty"`
+	IssueType      *IssueType `json:"issuetype,omitempty"`
+	Summary        *string    `json:"summary,omitempty"`
+	Description    *string    `json:"description,omitempty"`
+	Labels         []string   `json:"labels,omitempty"`
+	FixVersions    []Version  `json:"fixVersions,omitempty"`
+	Status         *Status    `json:"status,omitempty"`
+	Created        *Time      `json:"created,omitempty"`
+	Updated        *Time      `json:"updated,omitempty"`
+	ResolutionDate *Time      `json:"resolutiondate,omitempty"`
+}
+
+// fieldList is the fields query parameter matching Fields.
+const fieldList = "project,issuetype,summary,description,labels,fixVersions,status,created,updated,resolutiondate"
+
+// Issue mirrors the response of the jira issue API.
+type Issue struct {
+	ID     string `json:"id"`
+	Key    string `json:"key"`
+	Self   string `json:"self"`
+	Fields Fields `json:"fields"`
+}
+
+// IssueRequest is the body of the create and edit issue endpoints.
+type IssueRequest struct {
+	Fields Fields `json:"fields"`
+}
+
+// SearchResult is a page of the search endpoint.
+type SearchResult struct {
+	StartAt    int     `json:"startAt"`
+	MaxResults int     `json:"maxResults"`
+	Total      int     `json:"total"`
+	Issues     []Issue `json:"issues"`
+}
+
+type Transition struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	To   Status `json:"to"`
+}
+
+// TransitionsResult is the response of the list transitions endpoint.
+type TransitionsResult struct {
+	Transitions []Transition `json:"transitions"`
+}
+
+// TransitionRequest is the body of the do transition endpoint.
+type TransitionRequest struct {
+	Transition TransitionID `json:"transition"`
+}
+
+type TransitionID struct {
+	ID string `json:"id"`
+}
+
+// CommentRequest is the body of the add comment endpoint.
+type CommentRequest struct {
+	Body string `json:"body"`
+}
+
+// ErrorResponse is the body jira returns alongside a non 2xx status.
+type ErrorResponse struct {
+	ErrorMessages []string          `json:"errorMessages"`
+	Errors        map[string]string `json:"errors"`
+}
+
+func (e *ErrorResponse) message() string {
+	messages := append([]string{}, e.ErrorMessages...)
+	for field, message := range e.Errors {
+		messages = append(messages, field+": "+message)
+	}
+
+	return strings.Join(messages, ", ")
+}
+
+// toTracker converts an issue, issues in the done status category are closed. siteURL is used to build the browse link.
+func (i *Issue) toTracker(siteURL string) *tracker.Issue {
+	issue := &tracker.Issue{
+		Number:  issueNumber(i.Key),
+		Key:     i.Key,
+		State:   "open",
+		HTMLURL: siteURL + "/browse/" + i.Key,
+		Labels:  i.Fields.Labels,
+	}
+
+	if i.Fields.Summary != nil {
+		issue.Title = *i.Fields.Summary
+	}
+
+	if i.Fields.Description != nil {
+		issue.Body = *i.Fields.Description
+	}
+
+	if i.Fields.Status != nil && i.Fields.Status.StatusCategory.Key == "done" {
+		issue.State = "closed"
+	}
+
+	if len(i.Fields.FixVersions) != 0 {
+		issue.Milestone = i.Fields.FixVersions[0].Name
+	}
+
+	if i.Fields.Created != nil {
+		issue.CreatedAt = i.Fields.Created.Time
+	}
+
+	if i.Fields.Updated != nil {
+		issue.UpdatedAt = i.Fields.Updated.Time
+	}
+
+	if i.Fields.ResolutionDate != nil && !i.Fields.ResolutionDate.IsZero() {
+		closedAt := i.Fields.ResolutionDate.Time
+		issue.ClosedAt = &closedAt
+	}
+
+	return issue
+}
+
+// issueNumber is the sequence part of an issue key (12 for PROJ-12).
+func issueNumber(key string) int {
+	number, err := strconv.Atoi(key[strings.LastIndex(key, "-")+1:])
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
@@ -0,0 +1,150 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrBadCredentials is returned when the remote rejected the access token (401).
+	ErrBadCredentials = errors.New("bad credentials")
+	// ErrNotFound is returned when the repository or issue does not exist, or the token cannot see it (404).
+	ErrNotFound = errors.New("not found")
+	// ErrNoToken is returned by ContextToken when the context carries no access token.
+	ErrNoToken = errors.New("no access token in context")
+	// ErrUnsupported is returned for operations the remote has no equivalent of.
+	ErrUnsupported = errors.New("not supported by the tracker")
+)
+
+// RateLimitError is returned when the remote refused the call, or would refuse it, until ResetAt.
+type RateLimitError struct {
+	ResetAt time.Time
+	// Secondary is set when the remote's secondary (abuse) limiter kicked in instead of the hourly quota.
+	Secondary bool
+}
+
+func (e *RateLimitError) Error() string {
+	kind := "rate limit"
+	if e.Secondary {
+		kind = "secondary rate limit"
+	}
+
+	return fmt.Sprintf("%s exceeded until %s", kind, e.ResetAt.UTC().Format(time.RFC3339))
+}
+
+// RetryAfter is how long to wait from now before the call may be retried, never less than a second.
+func (e *RateLimitError) RetryAfter(now time.Time) time.Duration {
+	wait := e.ResetAt.Sub(now)
+	if wait < time.Second {
+		return time.Second
+	}
+
+	return wait
+}
+
+// IssueTracker is the set of remote operations the GithubIssue reconciler needs from an issue tracker.
+type IssueTracker interface {
+	ListIssues(ctx context.Context, repo Repository, options ListOptions) ([]Issue, error)
+	GetIssue(ctx context.Context, repo Repository, number int) (*Issue, error)
+	CreateIssue(ctx context.Context, repo Repository, request IssueRequest) (*Issue, error)
+	UpdateIssue(ctx context.Context, repo Repository, number int, request IssueRequest) (*Issue, error)
+	CloseIssue(ctx context.Context, repo Repository, number int) (*Issue, error)
+	ListLinkedPullRequests(ctx context.Context, repo Repository, number int) ([]PullRequest, error)
+	CreateComment(ctx context.Context, repo Repository, number int, body string) error
+	LockIssue(ctx context.Context, repo Repository, number int) error
+}
+
+// TokenSource returns the access token used to authenticate a single request.
+type TokenSource func(ctx context.Context) (string, error)
+
+type tokenContextKey struct{}
+
+// WithToken returns a copy of ctx carrying the access token every call made with it authenticates with,
+// so concurrent reconciles never see each other's credentials.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// ContextToken is the TokenSource reading the token stored by WithToken.
+func ContextToken(ctx context.Context) (string, error) {
+	token, ok := ctx.Value(tokenContextKey{}).(string)
+	if !ok {
+		return "", ErrNoToken
+	}
+
+	return token, nil
+}
+
+// Repository identifies the remote repository an issue lives in.
+type Repository struct {
+	// Host is the web host the repository lives on (e.g. github.com or a GitHub Enterprise Server host),
+	// trackers serving a single host ignore it.
+	Host  string
+	Owner string
+	Name  string
+}
+
+func (r Repository) String() string {
+	return fmt.Sprintf("%s/%s", r.Owner, r.Name)
+}
+
+// ListOptions filters the issues returned by ListIssues.
+type ListOptions struct {
+	// State is one of open, closed or all, an empty state lists only open issues.
+	State string
+}
+
+// Issue is the tracker independent view of a remote issue.
+type Issue struct {
+	Number int
+	// Key is the identifier the tracker displays when it is not just the number (e.g. PROJ-12 on jira).
+	Key   string
+	Title string
+	Body  string
+	State string
+	// StateReason is why the issue was closed (completed or not_planned), empty while open.
+	StateReason string
+	Locked      bool
+	HTMLURL     string
+	Labels      []string
+	Assignees   []string
+	Milestone   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ClosedAt    *time.Time
+}
+
+// IssueRequest holds the fields to send on create or update, nil fields are left untouched on the remote.
+type IssueRequest struct {
+	Title *string
+	Body  *string
+	State *string
+	// StateReason is only meaningful together with a closed state.
+	StateReason *string
+	Labels      []string
+	Assignees   []string
+	// Milestone is a milestone title, resolving it to the remote identifier is up to the tracker.
+	Milestone *string
+	// Type is the kind of work item to create on trackers that have them (the jira issue type), others ignore it.
+	Type *string
+}
+
+// PullRequest is a pull request linked to an issue, it may live in another repository than the issue.
+type PullRequest struct {
+	Repository Repository
+	Number     int
+	HTMLURL    string
+	// State is open or closed, a merged pull request is closed with Merged set.
+	State  string
+	Merged bool
+	// LinkedAt is when the pull request was linked to the issue, trackers not recording the link report when it was opened.
+	// It is zero when the tracker reports neither.
+	LinkedAt time.Time
+}
+
+// StringPtr returns a pointer to the given value, handy when building an IssueRequest.
+func StringPtr(value string) *string {
+	return &value
+}